@@ -28,13 +28,55 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
+// getStrategies reads the list of strategies the caching command has used
+func getStrategies(strategiesFile string) []string {
+	s, err := os.Open(strategiesFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.Close()
+
+	csvr := csv.NewReader(s)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	strategies := []string{}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		strategies = append(strategies, line[0])
+	}
+
+	return strategies
+}
+
 func main() {
-	if len(os.Args) != 3 {
+	// the caching strategy is optional, without it we analyze all of them
+	if len(os.Args) < 2 {
 		panic("not enough arguments given")
 	}
 
 	conf := os.Args[1]
-	cachingStrategy := os.Args[2]
+	args := os.Args[2:]
+
+	// the popularity mode breaks down hit ratios by item popularity and size instead
+	popularity := len(args) > 0 && args[0] == "popularity"
+
+	if popularity {
+		args = args[1:]
+	}
+
+	if len(args) > 1 {
+		panic("too many arguments given")
+	}
 
 	config, err := toml.LoadFile(conf)
 
@@ -65,8 +107,44 @@ func main() {
 
 	loadFile := path.Join(workloadFolder, workloadConfig.Get("loadfile").(string))
 
-	analysisFile := path.Join(workloadFolder, "analysis.csv"+cachingStrategy)
+	strategies := getStrategies(path.Join(workloadFolder, "cache", "strategies.csv"))
+
+	if len(args) == 1 {
+		found := false
+
+		for _, s := range strategies {
+			if s == args[0] {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			panic("Unknown caching strategy: " + args[0])
+		}
+
+		strategies = []string{args[0]}
+	}
+
+	if popularity {
+		dataFolder := path.Join(workloadFolder, "data")
+
+		err = os.MkdirAll(dataFolder, os.ModePerm)
+
+		if err != nil {
+			panic(err)
+		}
+
+		analyzePopularity(strategies, path.Join(dataFolder, "data.csvitemsummary.csv"), cacheFiles, loadFile, steps, stepLength)
+		return
+	}
+
+	for _, s := range strategies {
+		analyze(s, path.Join(workloadFolder, "analysis.csv"+s), cacheFiles, loadFile, steps, stepLength)
+	}
+}
 
+func analyze(cachingStrategy string, analysisFile string, cacheFiles string, loadFile string, steps int64, stepLength int64) {
 	f, err := os.Create(analysisFile)
 
 	if err != nil {
@@ -0,0 +1,301 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// items with at least this popularity share the last popularity bucket
+const maxPopularityBucket = 1000
+
+type itemInfo struct {
+	pop  float64
+	size float64
+}
+
+// bucketTotals are the sums over all requests in one bucket for one strategy
+type bucketTotals struct {
+	items    map[int64]struct{}
+	requests float64
+	bytes    float64
+	hits     float64
+	hitBytes float64
+}
+
+// getItemInfo reads the pop and size columns of the load file
+func getItemInfo(loadFile string) map[int64]itemInfo {
+	load, err := os.Open(loadFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer load.Close()
+
+	csvr := csv.NewReader(load)
+
+	header, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	popColumn, sizeColumn := -1, -1
+
+	for i, h := range header {
+		switch h {
+		case "pop":
+			popColumn = i
+		case "size":
+			sizeColumn = i
+		}
+	}
+
+	if popColumn < 0 || sizeColumn < 0 {
+		panic("load file " + loadFile + " has no pop or size column")
+	}
+
+	items := make(map[int64]itemInfo)
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		item, err := strconv.ParseInt(line[0], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		pop, err := strconv.ParseFloat(line[popColumn], 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		size, err := strconv.ParseFloat(line[sizeColumn], 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		items[item] = itemInfo{pop: pop, size: size}
+	}
+
+	return items
+}
+
+// powerOfTen is the largest power of ten that is at most x, or 1 for smaller values
+func powerOfTen(x float64) int64 {
+	if x < 10 {
+		return 1
+	}
+
+	b := int64(math.Pow(10, math.Floor(math.Log10(x))))
+
+	// guard against rounding errors of the logarithm
+	if float64(b) > x {
+		b /= 10
+	} else if float64(b*10) <= x {
+		b *= 10
+	}
+
+	return b
+}
+
+func popularityBucket(pop float64) int64 {
+	b := powerOfTen(pop)
+
+	if b > maxPopularityBucket {
+		return maxPopularityBucket
+	}
+
+	return b
+}
+
+func bucketName(kind string, b int64) string {
+	if kind == "popularity" && b == maxPopularityBucket {
+		return strconv.FormatInt(b, 10) + "+"
+	}
+
+	return strconv.FormatInt(b, 10)
+}
+
+// analyzePopularity sums up the items files of all steps and writes hit ratio and byte hit ratio per
+// popularity bucket (1, 10, 100, 1000+ expected accesses) and per size bucket (powers of ten in bytes)
+// for each strategy, one line per kind, bucket, and strategy
+func analyzePopularity(strategies []string, analysisFile string, cacheFiles string, loadFile string, steps int64, stepLength int64) {
+	items := getItemInfo(loadFile)
+
+	kinds := []string{"popularity", "size"}
+
+	// kind -> bucket -> strategy -> totals
+	totals := make(map[string]map[int64]map[string]*bucketTotals)
+
+	for _, k := range kinds {
+		totals[k] = make(map[int64]map[string]*bucketTotals)
+	}
+
+	add := func(kind string, b int64, s string, item int64, requests float64, hits float64, size float64) {
+		if _, ok := totals[kind][b]; !ok {
+			totals[kind][b] = make(map[string]*bucketTotals)
+		}
+
+		t, ok := totals[kind][b][s]
+
+		if !ok {
+			t = &bucketTotals{items: make(map[int64]struct{})}
+			totals[kind][b][s] = t
+		}
+
+		t.items[item] = struct{}{}
+		t.requests += requests
+		t.bytes += requests * size
+		t.hits += hits
+		t.hitBytes += hits * size
+	}
+
+	for _, s := range strategies {
+		pbar := progressbar.Default(steps)
+
+		var time int64 = 0
+
+		for ; time < steps*stepLength; time += stepLength {
+			pbar.Add(1)
+
+			itemsFile, err := os.Open(cacheFiles + strconv.FormatInt(time, 10) + s + "items")
+
+			// steps of a dropped warm-up period have no files
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			if err != nil {
+				panic(err)
+			}
+
+			csvr := csv.NewReader(itemsFile)
+
+			// skip header
+			if _, err = csvr.Read(); err != nil {
+				panic(err)
+			}
+
+			for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+				if err != nil {
+					panic(err)
+				}
+
+				item, err := strconv.ParseInt(line[0], 10, 64)
+
+				if err != nil {
+					panic(err)
+				}
+
+				requests, err := strconv.ParseFloat(line[1], 64)
+
+				if err != nil {
+					panic(err)
+				}
+
+				hits, err := strconv.ParseFloat(line[2], 64)
+
+				if err != nil {
+					panic(err)
+				}
+
+				info, ok := items[item]
+
+				if !ok {
+					panic("item " + line[0] + " is not in the load file")
+				}
+
+				add("popularity", popularityBucket(info.pop), s, item, requests, hits, info.size)
+				add("size", powerOfTen(info.size), s, item, requests, hits, info.size)
+			}
+
+			itemsFile.Close()
+		}
+	}
+
+	f, err := os.Create(analysisFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("kind,bucket,strategy,items,requests,bytes,hit_ratio,byte_hit_ratio\n")
+
+	for _, k := range kinds {
+		buckets := make([]int64, 0, len(totals[k]))
+
+		for b := range totals[k] {
+			buckets = append(buckets, b)
+		}
+
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+		for _, b := range buckets {
+			for _, s := range strategies {
+				t, ok := totals[k][b][s]
+
+				if !ok {
+					continue
+				}
+
+				byteHitRatio := 0.0
+				if t.bytes > 0 {
+					byteHitRatio = t.hitBytes / t.bytes
+				}
+
+				buf.WriteString(k)
+				buf.WriteString(",")
+				buf.WriteString(bucketName(k, b))
+				buf.WriteString(",")
+				buf.WriteString(s)
+				buf.WriteString(",")
+				buf.WriteString(strconv.Itoa(len(t.items)))
+				buf.WriteString(",")
+				buf.WriteString(strconv.FormatFloat(t.requests, 'f', -1, 64))
+				buf.WriteString(",")
+				buf.WriteString(strconv.FormatFloat(t.bytes, 'f', -1, 64))
+				buf.WriteString(",")
+				buf.WriteString(strconv.FormatFloat(t.hits/t.requests, 'f', -1, 64))
+				buf.WriteString(",")
+				buf.WriteString(strconv.FormatFloat(byteHitRatio, 'f', -1, 64))
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	buf.Flush()
+}
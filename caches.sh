@@ -1,3 +1,3 @@
 #!/bin/sh
 
-./caching/caching "$1"
\ No newline at end of file
+./caching/caching "$@"
\ No newline at end of file
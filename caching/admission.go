@@ -0,0 +1,289 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+const (
+	// every item is cached
+	admissionAll = "ALL"
+	// items are only cached when a node sees them for the second time, which keeps out one-hit wonders
+	admissionSecondHit = "SECOND-HIT"
+	// only items up to a size are cached
+	admissionSize = "SIZE"
+	// items are cached with a fixed probability
+	admissionProbabilistic = "PROBABILISTIC"
+
+	defaultAdmissionProbability = 0.1
+	// bits of the bloom filter of each node without a capacity, enough to remember 4096 items
+	defaultBloomBits = 1 << 16
+	// with a capacity, the filter remembers about as many items as fit into the cache, assuming this average item size
+	bloomAssumedItemSize = 10000
+	bloomBitsPerItem     = 16
+	bloomHashes          = 4
+)
+
+// admissionConfig describes which items node caches accept
+// it is read from the admission parameters of a [[strategy]] entry
+type admissionConfig struct {
+	policy      string
+	maxSize     int64
+	probability float64
+	bloomBits   int64
+	seed        int64
+}
+
+// the default size of the bloom filter depends on the capacity of the caches
+func getAdmissionConfig(conf *strategyConfig, capacity int64) admissionConfig {
+	a := admissionConfig{
+		policy: conf.getString("admission", admissionAll),
+	}
+
+	switch a.policy {
+	case admissionAll:
+	case admissionSecondHit:
+		bits := int64(defaultBloomBits)
+		if capacity > 0 {
+			bits = (capacity/bloomAssumedItemSize + 1) * bloomBitsPerItem
+		}
+
+		a.bloomBits = conf.getInt64("bloom_bits", bits)
+
+		if a.bloomBits <= 0 {
+			panic(conf.name + ": bloom_bits must be positive")
+		}
+	case admissionSize:
+		a.maxSize = conf.mustInt64("admission_size")
+
+		if a.maxSize <= 0 {
+			panic(conf.name + ": admission_size must be positive")
+		}
+	case admissionProbabilistic:
+		a.probability = conf.getFloat64("admission_probability", defaultAdmissionProbability)
+		a.seed = conf.seedFor("admission")
+
+		if a.probability <= 0 || a.probability > 1 {
+			panic(conf.name + ": admission_probability must be in (0, 1]")
+		}
+	default:
+		panic(conf.name + ": unknown admission " + a.policy + ", use " + admissionAll + ", " + admissionSecondHit + ", " + admissionSize + ", or " + admissionProbabilistic)
+	}
+
+	return a
+}
+
+// suffix is appended to the name of a strategy so that different admission policies can be told apart
+func (a admissionConfig) suffix() string {
+	switch a.policy {
+	case admissionSecondHit:
+		return "-" + a.policy
+	case admissionSize:
+		return "-" + a.policy + "-" + strconv.FormatInt(a.maxSize, 10)
+	case admissionProbabilistic:
+		return "-" + a.policy + "-" + strconv.FormatFloat(a.probability, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// admissionPolicy decides whether an item that a node does not have may be added to its cache
+type admissionPolicy interface {
+	admit(node int64, item int64, size int64) bool
+	// save writes the state of the policy to a checkpoint, load restores it into a new policy with the same configuration
+	save(w *checkpointWriter)
+	load(r *checkpointReader)
+}
+
+// newAdmissionPolicy returns nil if every item is admitted
+func newAdmissionPolicy(a admissionConfig) admissionPolicy {
+	switch a.policy {
+	case admissionSecondHit:
+		return &secondHitAdmission{
+			bits:    a.bloomBits,
+			filters: make(map[int64]*bloomFilter),
+		}
+	case admissionSize:
+		return &sizeAdmission{
+			maxSize: a.maxSize,
+		}
+	case admissionProbabilistic:
+		return &probabilisticAdmission{
+			probability: a.probability,
+			rng:         newSeededRand(a.seed),
+		}
+	}
+
+	return nil
+}
+
+// secondHitAdmission remembers the items each node has seen in a bloom filter
+type secondHitAdmission struct {
+	bits    int64
+	filters map[int64]*bloomFilter
+}
+
+func (A *secondHitAdmission) admit(node int64, item int64, size int64) bool {
+	f, ok := A.filters[node]
+
+	if !ok {
+		f = newBloomFilter(A.bits)
+		A.filters[node] = f
+	}
+
+	if f.has(item) {
+		return true
+	}
+
+	f.add(item)
+
+	return false
+}
+
+// bloomFilterState is how the filter of a node is stored in a checkpoint
+type bloomFilterState struct {
+	Node  int64
+	Bits  []uint64
+	Items int64
+}
+
+func (A *secondHitAdmission) save(w *checkpointWriter) {
+	nodes := make([]int64, 0, len(A.filters))
+
+	for node := range A.filters {
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i] < nodes[j]
+	})
+
+	filters := make([]bloomFilterState, 0, len(nodes))
+
+	for _, node := range nodes {
+		filters = append(filters, bloomFilterState{
+			Node:  node,
+			Bits:  A.filters[node].bits,
+			Items: A.filters[node].items,
+		})
+	}
+
+	w.write(filters)
+}
+
+func (A *secondHitAdmission) load(r *checkpointReader) {
+	var filters []bloomFilterState
+	r.read(&filters)
+
+	for _, s := range filters {
+		f := newBloomFilter(A.bits)
+		copy(f.bits, s.Bits)
+		f.items = s.Items
+
+		A.filters[s.Node] = f
+	}
+}
+
+type sizeAdmission struct {
+	maxSize int64
+}
+
+func (A *sizeAdmission) admit(node int64, item int64, size int64) bool {
+	return size <= A.maxSize
+}
+
+// sizeAdmission does not remember anything
+func (A *sizeAdmission) save(w *checkpointWriter) {}
+
+func (A *sizeAdmission) load(r *checkpointReader) {}
+
+type probabilisticAdmission struct {
+	probability float64
+	rng         *seededRand
+}
+
+func (A *probabilisticAdmission) admit(node int64, item int64, size int64) bool {
+	return A.rng.Float64() < A.probability
+}
+
+func (A *probabilisticAdmission) save(w *checkpointWriter) {
+	A.rng.save(w)
+}
+
+func (A *probabilisticAdmission) load(r *checkpointReader) {
+	A.rng.load(r)
+}
+
+// bloomFilter is cleared once it holds so many items that it would give too many false positives
+type bloomFilter struct {
+	bits    []uint64
+	size    uint64
+	items   int64
+	maxSize int64
+}
+
+func newBloomFilter(bits int64) *bloomFilter {
+	return &bloomFilter{
+		bits: make([]uint64, (bits+63)/64),
+		size: uint64(bits),
+		// with 4 hashes and 16 bits per item, about 0.2% of lookups are false positives
+		maxSize: bits/bloomBitsPerItem + 1,
+	}
+}
+
+func (f *bloomFilter) index(item int64, hash int) uint64 {
+	return splitmix64(uint64(item)+uint64(hash+1)*0x9e3779b97f4a7c15) % f.size
+}
+
+func (f *bloomFilter) has(item int64) bool {
+	for h := 0; h < bloomHashes; h++ {
+		i := f.index(item, h)
+
+		if f.bits[i/64]&(1<<(i%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *bloomFilter) add(item int64) {
+	if f.items >= f.maxSize {
+		for i := range f.bits {
+			f.bits[i] = 0
+		}
+
+		f.items = 0
+	}
+
+	for h := 0; h < bloomHashes; h++ {
+		i := f.index(item, h)
+		f.bits[i/64] |= 1 << (i % 64)
+	}
+
+	f.items++
+}
+
+// splitmix64 mixes the bits of x, which gives good enough hashes for item ids
+func splitmix64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
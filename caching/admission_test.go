@@ -0,0 +1,50 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+// the bloom filter of SECOND-HIT grows with the capacity of the caches unless its size is given
+func TestSecondHitBloomBits(t *testing.T) {
+	cases := []struct {
+		conf string
+		bits int64
+	}{
+		{conf: `admission = "SECOND-HIT"`, bits: defaultBloomBits},
+		{conf: "admission = \"SECOND-HIT\"\ncapacity = 1000000", bits: 101 * bloomBitsPerItem},
+		{conf: "admission = \"SECOND-HIT\"\ncapacity = 1000000\nbloom_bits = 128", bits: 128},
+	}
+
+	for _, c := range cases {
+		tree, err := toml.Load(c.conf)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conf := getCacheConfig(&strategyConfig{configTable: configTable{name: "test", tree: tree}})
+
+		if conf.admission.bloomBits != c.bits {
+			t.Errorf("%q: %d bloom bits, want %d", c.conf, conf.admission.bloomBits, c.bits)
+		}
+	}
+}
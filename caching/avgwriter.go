@@ -23,7 +23,6 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 )
 
 type aw struct {
@@ -41,9 +40,38 @@ type aw struct {
 	cachedStoreRecordsNum map[string]int64
 
 	storeNodesPerStrategy map[string]int64
+	incrementalStore      map[string]bool
+
+	// steps before this time are the warm-up period
+	warmup int64
+	// records of the warm-up period are either dropped or tagged
+	dropWarmup bool
+
+	// nil if links are not limited
+	links *linkCapacities
+
+	latency *latencyModel
+
+	// nil if energy is not accounted for
+	energy *energyModel
+
+	locations *locationTable
+
+	// percentiles over the whole run after the warm-up period, written when the writer is done
+	run runSummary
+}
+
+// writerState is what the writer remembers between steps, it is written to checkpoints
+type writerState struct {
+	CachedStoreRecords    map[string]map[int64]int64
+	CachedStoreRecordsNum map[string]int64
+	Run                   runSummary
 }
 
-func newAvgWriter(filename string, itemSizes *map[int64]int64, c <-chan writeSet, storeNodesPerStrategy map[string]int64) {
+// newAvgWriter writes the records it receives until it gets a writeSet with time -1
+// it then writes the whole-run summary and closes the done channel of that writeSet
+// a run that is continued from a checkpoint passes the state the writer had then, otherwise state is nil
+func newAvgWriter(filename string, itemSizes *map[int64]int64, c <-chan writeSet, storeNodesPerStrategy map[string]int64, incrementalStore map[string]bool, warmup int64, dropWarmup bool, links *linkCapacities, latency *latencyModel, energy *energyModel, locations *locationTable, state *writerState) {
 
 	f := aw{
 		filename:              filename,
@@ -51,14 +79,76 @@ func newAvgWriter(filename string, itemSizes *map[int64]int64, c <-chan writeSet
 		cachedStoreRecords:    make(map[string]map[int64]int64),
 		cachedStoreRecordsNum: make(map[string]int64),
 		storeNodesPerStrategy: storeNodesPerStrategy,
+		incrementalStore:      incrementalStore,
+		warmup:                warmup,
+		dropWarmup:            dropWarmup,
+		links:                 links,
+		latency:               latency,
+		energy:                energy,
+		locations:             locations,
+		run:                   make(runSummary),
+	}
+
+	if state != nil {
+		f.setState(state)
 	}
 
 	for w := range c {
 		if w.time == -1 {
+			f.run.write(f.filename + "summary")
+			close(w.done)
 			break
 		}
 
-		f.write(w.time, w.strategyName, w.txRecords, w.storeRecords, w.cacheRecords, w.hopsRecords)
+		if w.state != nil {
+			w.state <- f.getState()
+			continue
+		}
+
+		f.write(w.time, w.strategyName, w.txRecords, w.storeRecords, w.cacheRecords, w.hopsRecords, w.latencyRecords, w.metrics)
+	}
+}
+
+// getState copies the state of the writer, so that it can be written while the writer goes on
+func (f *aw) getState() *writerState {
+	s := &writerState{
+		CachedStoreRecords:    make(map[string]map[int64]int64, len(f.cachedStoreRecords)),
+		CachedStoreRecordsNum: make(map[string]int64, len(f.cachedStoreRecordsNum)),
+	}
+
+	for strategy, perNode := range f.cachedStoreRecords {
+		s.CachedStoreRecords[strategy] = make(map[int64]int64, len(perNode))
+
+		for node, store := range perNode {
+			s.CachedStoreRecords[strategy][node] = store
+		}
+	}
+
+	for strategy, num := range f.cachedStoreRecordsNum {
+		s.CachedStoreRecordsNum[strategy] = num
+	}
+
+	s.Run = f.run.copy()
+
+	return s
+}
+
+func (f *aw) setState(s *writerState) {
+	for strategy, perNode := range s.CachedStoreRecords {
+		f.cachedStoreRecords[strategy] = make(map[int64]int64, len(perNode))
+
+		for node, store := range perNode {
+			f.cachedStoreRecords[strategy][node] = store
+		}
+	}
+
+	for strategy, num := range s.CachedStoreRecordsNum {
+		f.cachedStoreRecordsNum[strategy] = num
+	}
+
+	// checkpoints of older runs do not have a summary
+	if s.Run != nil {
+		f.run = s.Run.copy()
 	}
 }
 
@@ -85,12 +175,65 @@ func (f *aw) calcPercentile(val *[]int, p int64) float64 {
 	return float64((*val)[k-1]) + frac*(float64((*val)[k]-(*val)[k-1]))
 }
 
-func (f *aw) write(time int64, strategyName string, txRecords *[]txRecord, storeRecords *[]storeRecord, cacheRecords *[]cacheRecord, hopsRecords *[]hopsRecord) {
+func (f *aw) write(time int64, strategyName string, txRecords *[]txRecord, storeRecords *[]storeRecord, cacheRecords *[]cacheRecord, hopsRecords *[]hopsRecord, latencyRecords *[]latencyRecord, metrics *[]metric) {
+	// incremental stores still need to know what was stored during the warm-up period
+	if time < f.warmup && f.dropWarmup {
+		f.storePerNode(strategyName, storeRecords)
+		return
+	}
+
 	baseFilename := f.filename + strconv.FormatInt(time, 10) + strategyName
-	f.writeTX(baseFilename+"tx", txRecords)
-	f.writeStore(baseFilename+"store", strategyName, storeRecords)
-	f.writeCache(baseFilename+"cache", cacheRecords)
+	strPerNode := f.storePerNode(strategyName, storeRecords)
+	flowPerSat := flowPerSat(txRecords)
+
+	latencies := make([]float64, len(*latencyRecords))
+
+	for i, r := range *latencyRecords {
+		latencies[i] = f.latency.latency(r)
+	}
+
+	if time >= f.warmup {
+		f.run.add(strategyName, hopsRecords, latencies, flowPerSat, strPerNode, f.storeNodesPerStrategy[strategyName])
+	}
+
+	f.writeTX(baseFilename+"tx", txRecords, flowPerSat)
+	f.writeStore(baseFilename+"store", strategyName, strPerNode)
+	f.writeCache(baseFilename+"cache", cacheRecords, time < f.warmup)
 	f.writeHops(baseFilename+"hops", hopsRecords)
+	f.writeLatency(baseFilename+"latency", latencies)
+	writeItems(baseFilename+"items", cacheRecords)
+	writeOrigins(baseFilename+"origins", cacheRecords)
+	f.locations.writeLocations(baseFilename+"locations", cacheRecords, hopsRecords, latencyRecords, f.latency)
+
+	if f.links != nil {
+		f.links.writeLinks(baseFilename+"links", txRecords, len(*cacheRecords))
+	}
+
+	if f.energy != nil {
+		f.energy.writeEnergy(baseFilename+"energy", txRecords, strPerNode)
+	}
+
+	// only some strategies have their own metrics
+	if metrics != nil {
+		f.writeMetrics(baseFilename+"metrics", metrics)
+	}
+}
+
+// flowPerSat sums up the data each satellite sends and receives, ground stations are ignored
+func flowPerSat(records *[]txRecord) map[int64]int64 {
+	flow := make(map[int64]int64)
+
+	for _, r := range *records {
+		if r.source >= 0 {
+			flow[r.source] += r.bandwidth
+		}
+
+		if r.target >= 0 {
+			flow[r.target] += r.bandwidth
+		}
+	}
+
+	return flow
 }
 
 // writeTX writes the following to file:
@@ -101,23 +244,16 @@ func (f *aw) write(time int64, strategyName string, txRecords *[]txRecord, store
 // * median data flow per sat
 // * 95th pcntl data flow per sat
 // * 99th pcntl data flow per sat
-func (f *aw) writeTX(filename string, records *[]txRecord) {
+// * total data flow of each kind of traffic
+func (f *aw) writeTX(filename string, records *[]txRecord, flowPerSat map[int64]int64) {
 
 	var total int64
 
-	flowPerSat := make(map[int64]int64)
+	totalPerKind := make([]int64, len(txKinds))
 
 	for _, r := range *records {
 		total += r.bandwidth
-
-		// ignore gst
-		if r.source >= 0 {
-			flowPerSat[r.source] += r.bandwidth
-		}
-
-		if r.target >= 0 {
-			flowPerSat[r.target] += r.bandwidth
-		}
+		totalPerKind[r.kind] += r.bandwidth
 	}
 
 	var maxFlow int
@@ -198,24 +334,24 @@ func (f *aw) writeTX(filename string, records *[]txRecord) {
 	buf.WriteString(strconv.FormatFloat(p99, 'f', -1, 64))
 	buf.WriteString("\n")
 
+	// * total data flow of each kind of traffic
+	for kind, name := range txKinds {
+		buf.WriteString("total_")
+		buf.WriteString(name)
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(totalPerKind[kind], 10))
+		buf.WriteString("\n")
+	}
+
 	buf.Flush()
 }
 
-// writeStore writes the following to file
-// * total storage per store node
-// * max storage use per store node
-// * min storage use per store node
-// * avg storage use per store node
-// * median storage use per store node
-// * 95th pcntl storage use per store node
-// * 99th pcntl storage use per store node
-// * amount of nodes
-// * amount of nodes without store
-func (f *aw) writeStore(filename string, strategyName string, records *[]storeRecord) {
+// storePerNode sums up the size of the items each node stores
+func (f *aw) storePerNode(strategyName string, records *[]storeRecord) map[int64]int64 {
 
 	strPerNode := make(map[int64]int64)
 
-	if strings.Contains(strategyName, "GROUND-STATION") {
+	if f.incrementalStore[strategyName] {
 		if _, ok := f.cachedStoreRecords[strategyName]; !ok {
 			f.cachedStoreRecords[strategyName] = make(map[int64]int64)
 		}
@@ -234,6 +370,21 @@ func (f *aw) writeStore(filename string, strategyName string, records *[]storeRe
 		}
 	}
 
+	return strPerNode
+}
+
+// writeStore writes the following to file
+// * total storage per store node
+// * max storage use per store node
+// * min storage use per store node
+// * avg storage use per store node
+// * median storage use per store node
+// * 95th pcntl storage use per store node
+// * 99th pcntl storage use per store node
+// * amount of nodes
+// * amount of nodes without store
+func (f *aw) writeStore(filename string, strategyName string, strPerNode map[int64]int64) {
+
 	var total int64
 
 	var maxStore int
@@ -331,19 +482,44 @@ func (f *aw) writeStore(filename string, strategyName string, records *[]storeRe
 
 // writeCache writes the following to file
 // * cache hit ratio
+// * byte hit ratio, i.e., the share of requested bytes served from a cache
 // * number of requests
-func (f *aw) writeCache(filename string, records *[]cacheRecord) {
+// * stale hit ratio, i.e., requests served from an outdated copy
+// * whether the step is part of the warm-up period (1) or not (0)
+func (f *aw) writeCache(filename string, records *[]cacheRecord, warmup bool) {
 	numSuccess := 0
+	var bytes, hitBytes int64
+	numStale := 0
 	numRequests := 0
+	numFailed := 0
+	numFailedSuccess := 0
 
 	for _, r := range *records {
 		if r.success {
 			numSuccess++
+			hitBytes += r.bytes
+		}
+		bytes += r.bytes
+		if r.stale {
+			numStale++
+		}
+		if r.failed {
+			numFailed++
+
+			if r.success {
+				numFailedSuccess++
+			}
 		}
 		numRequests++
 	}
 
 	ratio := float64(numSuccess) / float64(numRequests)
+	byteRatio := 0.0
+	if bytes > 0 {
+		byteRatio = float64(hitBytes) / float64(bytes)
+	}
+	staleRatio := float64(numStale) / float64(numRequests)
+	failedRatio := float64(numFailed) / float64(numRequests)
 
 	cacheFile, err := os.Create(filename)
 
@@ -359,10 +535,91 @@ func (f *aw) writeCache(filename string, records *[]cacheRecord) {
 	buf.WriteString(strconv.FormatFloat(ratio, 'f', -1, 64))
 	buf.WriteString("\n")
 
+	buf.WriteString("byte_ratio,")
+	buf.WriteString(strconv.FormatFloat(byteRatio, 'f', -1, 64))
+	buf.WriteString("\n")
+
 	buf.WriteString("num_requests,")
 	buf.WriteString(strconv.Itoa(numRequests))
 	buf.WriteString("\n")
 
+	buf.WriteString("stale_ratio,")
+	buf.WriteString(strconv.FormatFloat(staleRatio, 'f', -1, 64))
+	buf.WriteString("\n")
+
+	buf.WriteString("failed_ratio,")
+	buf.WriteString(strconv.FormatFloat(failedRatio, 'f', -1, 64))
+	buf.WriteString("\n")
+
+	// difference between the hit ratio of requests routed through a failed satellite or link and that of all others
+	// empty if there are no requests to compare
+	buf.WriteString("failed_hit_ratio_change,")
+	if numFailed > 0 && numFailed < numRequests {
+		failedHitRatio := float64(numFailedSuccess) / float64(numFailed)
+		otherHitRatio := float64(numSuccess-numFailedSuccess) / float64(numRequests-numFailed)
+
+		buf.WriteString(strconv.FormatFloat(failedHitRatio-otherHitRatio, 'f', -1, 64))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString("warmup,")
+	if warmup {
+		buf.WriteString("1")
+	} else {
+		buf.WriteString("0")
+	}
+	buf.WriteString("\n")
+
+	buf.Flush()
+}
+
+// writeLatency writes the following to file, all in milliseconds
+// * avg latency of requests
+// * median latency of requests
+// * 95th pcntl latency of requests
+// * 99th pcntl latency of requests
+func (f *aw) writeLatency(filename string, latency []float64) {
+
+	var totalLatency float64
+
+	for _, l := range latency {
+		totalLatency += l
+	}
+
+	sort.Float64s(latency)
+
+	avgLatency := totalLatency / float64(len(latency))
+
+	medianLatency := percentile(latency, 50)
+	p95 := percentile(latency, 95)
+	p99 := percentile(latency, 99)
+
+	latencyFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer latencyFile.Close()
+
+	buf := bufio.NewWriter(latencyFile)
+
+	buf.WriteString("avg,")
+	buf.WriteString(strconv.FormatFloat(avgLatency, 'f', -1, 64))
+	buf.WriteString("\n")
+
+	buf.WriteString("median,")
+	buf.WriteString(strconv.FormatFloat(medianLatency, 'f', -1, 64))
+	buf.WriteString("\n")
+
+	buf.WriteString("95th,")
+	buf.WriteString(strconv.FormatFloat(p95, 'f', -1, 64))
+	buf.WriteString("\n")
+
+	buf.WriteString("99th,")
+	buf.WriteString(strconv.FormatFloat(p99, 'f', -1, 64))
+	buf.WriteString("\n")
+
 	buf.Flush()
 }
 
@@ -445,3 +702,25 @@ func (f *aw) writeHops(filename string, records *[]hopsRecord) {
 
 	buf.Flush()
 }
+
+// writeMetrics writes strategy-specific metrics to file, one per line
+func (f *aw) writeMetrics(filename string, metrics *[]metric) {
+	metricsFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer metricsFile.Close()
+
+	buf := bufio.NewWriter(metricsFile)
+
+	for _, m := range *metrics {
+		buf.WriteString(m.name)
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(m.value, 'f', -1, 64))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
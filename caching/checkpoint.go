@@ -0,0 +1,165 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"encoding/gob"
+	"os"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// checkpoints hold everything that is needed to continue a run at a later step:
+// the state of every strategy and the state of the writer
+// they are gob streams, so all state is copied into structs with exported fields before it is written
+
+// checkpointWriter writes the state of a run, it panics on errors
+type checkpointWriter struct {
+	enc *gob.Encoder
+}
+
+func (w *checkpointWriter) write(v interface{}) {
+	if err := w.enc.Encode(v); err != nil {
+		panic(err)
+	}
+}
+
+// checkpointReader reads what a checkpointWriter has written, in the same order
+// values must be read into zero values, as gob does not overwrite fields with zero values
+type checkpointReader struct {
+	dec *gob.Decoder
+}
+
+func (r *checkpointReader) read(v interface{}) {
+	if err := r.dec.Decode(v); err != nil {
+		panic(err)
+	}
+}
+
+// checkpointHeader is at the beginning of every checkpoint
+type checkpointHeader struct {
+	// the step the run continues with
+	Time       int64
+	Strategies []string
+}
+
+// getCheckpointSteps returns after how many steps a checkpoint is written, 0 means never
+func getCheckpointSteps(workloadConfig *toml.Tree) int64 {
+	c := configTable{
+		name: "workload",
+		tree: workloadConfig,
+	}
+
+	steps := c.getInt64("checkpoint_steps", 0)
+
+	if steps < 0 {
+		panic("checkpoint_steps must not be negative")
+	}
+
+	return steps
+}
+
+// writeCheckpoint saves the state of all strategies and the writer before the step at time
+// the file is replaced atomically, so there is always one complete checkpoint
+func writeCheckpoint(filename string, time int64, C []strategy, writer *writerState) {
+	tmp := filename + ".tmp"
+
+	f, err := os.Create(tmp)
+
+	if err != nil {
+		panic(err)
+	}
+
+	w := &checkpointWriter{
+		enc: gob.NewEncoder(f),
+	}
+
+	header := checkpointHeader{
+		Time: time,
+	}
+
+	for _, c := range C {
+		header.Strategies = append(header.Strategies, c.getName())
+	}
+
+	w.write(header)
+
+	for _, c := range C {
+		c.save(w)
+	}
+
+	w.write(writer)
+
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+
+	if err := os.Rename(tmp, filename); err != nil {
+		panic(err)
+	}
+}
+
+// readCheckpoint restores the state of newly created strategies and returns the time of the next step and the state of the writer
+// the strategies have to be the same as when the checkpoint was written
+func readCheckpoint(filename string, C []strategy) (int64, *writerState) {
+	f, err := os.Open(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	r := &checkpointReader{
+		dec: gob.NewDecoder(f),
+	}
+
+	var header checkpointHeader
+	r.read(&header)
+
+	if len(header.Strategies) != len(C) {
+		panic("checkpoint has " + strconv.Itoa(len(header.Strategies)) + " strategies, but " + strconv.Itoa(len(C)) + " are configured")
+	}
+
+	for i, c := range C {
+		if header.Strategies[i] != c.getName() {
+			panic("checkpoint has strategy " + header.Strategies[i] + " where " + c.getName() + " is configured")
+		}
+
+		c.load(r)
+	}
+
+	var writer writerState
+	r.read(&writer)
+
+	return header.Time, &writer
+}
+
+// listItems returns the items in a list of item ids from the back to the front
+// pushing them to the front of a new list in that order restores the list
+func listItems(l *list.List) []int64 {
+	items := make([]int64, 0, l.Len())
+
+	for e := l.Back(); e != nil; e = e.Prev() {
+		items = append(items, e.Value.(int64))
+	}
+
+	return items
+}
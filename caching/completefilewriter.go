@@ -36,19 +36,34 @@ func newFileWriter(filename string, c <-chan writeSet) {
 	for w := range c {
 
 		if w.time == -1 {
+			close(w.done)
 			break
 		}
 
-		f.write(w.time, w.strategyName, w.txRecords, w.storeRecords, w.cacheRecords, w.hopsRecords)
+		// this writer does not remember anything between steps
+		if w.state != nil {
+			w.state <- &writerState{}
+			continue
+		}
+
+		f.write(w.time, w.strategyName, w.txRecords, w.storeRecords, w.cacheRecords, w.hopsRecords, w.latencyRecords, w.metrics)
 	}
 }
 
-func (f *fw) write(time int64, strategyName string, txRecords *[]txRecord, storeRecords *[]storeRecord, cacheRecords *[]cacheRecord, hopsRecords *[]hopsRecord) {
+func (f *fw) write(time int64, strategyName string, txRecords *[]txRecord, storeRecords *[]storeRecord, cacheRecords *[]cacheRecord, hopsRecords *[]hopsRecord, latencyRecords *[]latencyRecord, metrics *[]metric) {
 	baseFilename := f.filename + strconv.FormatInt(time, 10) + strategyName
 	f.writeTX(baseFilename+"tx", txRecords)
 	f.writeStore(baseFilename+"store", storeRecords)
 	f.writeCache(baseFilename+"cache", cacheRecords)
 	f.writeHops(baseFilename+"hops", hopsRecords)
+	f.writeLatency(baseFilename+"latency", latencyRecords)
+	writeItems(baseFilename+"items", cacheRecords)
+	writeOrigins(baseFilename+"origins", cacheRecords)
+
+	// only some strategies have their own metrics
+	if metrics != nil {
+		f.writeMetrics(baseFilename+"metrics", metrics)
+	}
 }
 
 func (f *fw) writeTX(filename string, records *[]txRecord) {
@@ -62,7 +77,7 @@ func (f *fw) writeTX(filename string, records *[]txRecord) {
 
 	buf := bufio.NewWriter(txFile)
 
-	buf.WriteString("source,target,bandwidth\n")
+	buf.WriteString("source,target,bandwidth,kind\n")
 
 	for _, r := range *records {
 		source := r.source
@@ -78,6 +93,8 @@ func (f *fw) writeTX(filename string, records *[]txRecord) {
 		buf.WriteString(strconv.FormatInt(target, 10))
 		buf.WriteString(",")
 		buf.WriteString(strconv.FormatInt(r.bandwidth, 10))
+		buf.WriteString(",")
+		buf.WriteString(txKinds[r.kind])
 		buf.WriteString("\n")
 	}
 
@@ -118,13 +135,23 @@ func (f *fw) writeCache(filename string, records *[]cacheRecord) {
 
 	buf := bufio.NewWriter(cacheFile)
 
-	buf.WriteString("item,success\n")
+	buf.WriteString("item,success,stale,failed,gst,bytes,origin\n")
 
 	for _, r := range *records {
 
 		buf.WriteString(strconv.FormatInt(r.item, 10))
 		buf.WriteString(",")
 		buf.WriteString(strconv.FormatBool(r.success))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatBool(r.stale))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatBool(r.failed))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.gst, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.bytes, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.origin, 10))
 		buf.WriteString("\n")
 	}
 
@@ -142,12 +169,64 @@ func (f *fw) writeHops(filename string, records *[]hopsRecord) {
 
 	buf := bufio.NewWriter(hopsFile)
 
-	buf.WriteString("item,hops\n")
+	buf.WriteString("item,hops,gst\n")
+
+	for _, r := range *records {
+		buf.WriteString(strconv.FormatInt(r.item, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.hops, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.gst, 10))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
+
+func (f *fw) writeLatency(filename string, records *[]latencyRecord) {
+	latencyFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer latencyFile.Close()
+
+	buf := bufio.NewWriter(latencyFile)
+
+	buf.WriteString("item,distance,hops,gst\n")
 
 	for _, r := range *records {
 		buf.WriteString(strconv.FormatInt(r.item, 10))
 		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.distance, 10))
+		buf.WriteString(",")
 		buf.WriteString(strconv.FormatInt(r.hops, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(r.gst, 10))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
+
+func (f *fw) writeMetrics(filename string, metrics *[]metric) {
+	metricsFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer metricsFile.Close()
+
+	buf := bufio.NewWriter(metricsFile)
+
+	buf.WriteString("name,value\n")
+
+	for _, m := range *metrics {
+		buf.WriteString(m.name)
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(m.value, 'f', -1, 64))
 		buf.WriteString("\n")
 	}
 
@@ -0,0 +1,335 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// defaultStrategies is used for workloads that do not have a [[strategy]] list
+// it matches the strategies we used in the paper
+const defaultStrategies = `
+[[strategy]]
+type = "NONE"
+
+[[strategy]]
+type = "GROUND-STATION"
+max_clients = 10000
+
+[[strategy]]
+type = "GROUND-STATION"
+max_clients = 100
+
+[[strategy]]
+type = "GROUND-STATION"
+max_clients = 10
+
+[[strategy]]
+type = "SATELLITE"
+
+[[strategy]]
+type = "SATELLITE-TIMEOUT"
+
+[[strategy]]
+type = "SATELLITE-VIRTUAL"
+`
+
+// configTable is a table in the workload config with typed accessors for its keys
+type configTable struct {
+	name string
+	tree *toml.Tree
+}
+
+// strategyConfig is a single [[strategy]] entry in the workload config
+type strategyConfig struct {
+	configTable
+	kind string
+	// seed of the run, all random numbers a strategy draws are derived from it
+	seed int64
+	// appended to the name of the strategy to tell apart the points of a parameter sweep
+	point string
+	// appended after that to tell apart replications with different seeds
+	replication string
+}
+
+// getConfigTable returns the table with the given key, or an empty table if there is none
+func getConfigTable(workloadConfig *toml.Tree, key string) configTable {
+	if !workloadConfig.Has(key) {
+		empty, err := toml.TreeFromMap(map[string]interface{}{})
+
+		if err != nil {
+			panic(err)
+		}
+
+		return configTable{
+			name: key,
+			tree: empty,
+		}
+	}
+
+	t, ok := workloadConfig.Get(key).(*toml.Tree)
+
+	if !ok {
+		panic(key + " must be a table")
+	}
+
+	return configTable{
+		name: key,
+		tree: t,
+	}
+}
+
+// getStrategyConfigs returns one config per [[strategy]] entry and point of its parameter sweep
+// with replications, there are that many instances of each, each with its own seed
+func getStrategyConfigs(workloadConfig *toml.Tree) []*strategyConfig {
+	c := configTable{
+		name: "workload",
+		tree: workloadConfig,
+	}
+
+	seed := c.getInt64("seed", 0)
+	replications := c.getInt64("replications", 1)
+
+	if replications < 1 {
+		panic("replications must be at least 1")
+	}
+
+	if !workloadConfig.Has("strategy") {
+		def, err := toml.Load(defaultStrategies)
+
+		if err != nil {
+			panic(err)
+		}
+
+		workloadConfig = def
+	}
+
+	trees, ok := workloadConfig.Get("strategy").([]*toml.Tree)
+
+	if !ok {
+		panic("strategy must be an array of tables, use [[strategy]]")
+	}
+
+	confs := make([]*strategyConfig, 0, int64(len(trees))*replications)
+
+	for i, t := range trees {
+		kind, ok := t.Get("type").(string)
+
+		if !ok {
+			panic("strategy " + strconv.Itoa(i) + " has no type")
+		}
+
+		for _, p := range getSweep(kind, t) {
+			for r := int64(0); r < replications; r++ {
+				conf := &strategyConfig{
+					configTable: configTable{
+						name: kind,
+						tree: p.tree,
+					},
+					kind:  kind,
+					seed:  seed + r,
+					point: p.tag,
+				}
+
+				if replications > 1 {
+					conf.replication = "-SEED-" + strconv.FormatInt(conf.seed, 10)
+				}
+
+				confs = append(confs, conf)
+			}
+		}
+	}
+
+	return confs
+}
+
+const (
+	// records of the warm-up period are written, but tagged as such
+	warmupTag = "TAG"
+	// records of the warm-up period are not written at all
+	warmupDrop = "DROP"
+)
+
+// getWarmup returns the time at which the warm-up period ends and whether its records are dropped
+// the warm-up period is set with either warmup_steps or warmup_seconds, without them there is none
+func getWarmup(workloadConfig *toml.Tree, stepLength int64) (int64, bool) {
+	c := configTable{
+		name: "workload",
+		tree: workloadConfig,
+	}
+
+	if c.has("warmup_steps") && c.has("warmup_seconds") {
+		panic("only one of warmup_steps and warmup_seconds may be set")
+	}
+
+	warmup := c.getInt64("warmup_steps", 0) * stepLength
+
+	if c.has("warmup_seconds") {
+		warmup = c.getInt64("warmup_seconds", 0)
+	}
+
+	if warmup < 0 {
+		panic("warm-up period must not be negative")
+	}
+
+	records := c.getString("warmup_records", warmupTag)
+
+	if records != warmupTag && records != warmupDrop {
+		panic("unknown warmup_records " + records + ", use " + warmupTag + " or " + warmupDrop)
+	}
+
+	return warmup, records == warmupDrop
+}
+
+// writeWarmup writes the end of the warm-up period and what happened to its records, so that graph does not have to work it out again
+func writeWarmup(filename string, warmup int64, dropWarmup bool) {
+	f, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("warmup,records\n")
+	buf.WriteString(strconv.FormatInt(warmup, 10))
+	buf.WriteString(",")
+
+	if dropWarmup {
+		buf.WriteString(warmupDrop)
+	} else {
+		buf.WriteString(warmupTag)
+	}
+
+	buf.WriteString("\n")
+
+	buf.Flush()
+}
+
+// getName returns the name given in the config, or def if there is none, followed by the tags of the instance
+func (s *strategyConfig) getName(def string) string {
+	return s.getString("name", def) + s.point + s.replication
+}
+
+// seedFor derives the seed of the random number generator a strategy uses for one purpose, e.g., eviction
+// all strategies draw the same random numbers for the same purpose, so that differences between them are not down to chance
+func (s *strategyConfig) seedFor(purpose string) int64 {
+	return deriveSeed(s.seed, purpose)
+}
+
+// deriveSeed combines the seed of a run with a purpose, so that random numbers drawn for one purpose do not depend on any other
+func deriveSeed(seed int64, purpose string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(purpose))
+
+	return int64(splitmix64(uint64(seed) ^ h.Sum64()))
+}
+
+func (s *configTable) has(key string) bool {
+	return s.tree.Has(key)
+}
+
+func (s *configTable) getInt64(key string, def int64) int64 {
+	if !s.tree.Has(key) {
+		return def
+	}
+
+	v, ok := s.tree.Get(key).(int64)
+
+	if !ok {
+		panic(s.name + ": parameter " + key + " must be an integer")
+	}
+
+	return v
+}
+
+func (s *configTable) mustInt64(key string) int64 {
+	if !s.tree.Has(key) {
+		panic(s.name + ": missing parameter " + key)
+	}
+
+	return s.getInt64(key, 0)
+}
+
+func (s *configTable) getFloat64(key string, def float64) float64 {
+	if !s.tree.Has(key) {
+		return def
+	}
+
+	// toml has no implicit conversion, but writing 1 instead of 1.0 should be fine
+	switch v := s.tree.Get(key).(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+
+	panic(s.name + ": parameter " + key + " must be a number")
+}
+
+func (s *configTable) getString(key string, def string) string {
+	if !s.tree.Has(key) {
+		return def
+	}
+
+	v, ok := s.tree.Get(key).(string)
+
+	if !ok {
+		panic(s.name + ": parameter " + key + " must be a string")
+	}
+
+	return v
+}
+
+// writeStrategies writes the names of all strategies to a file
+// analysis and graph read this file to know which cache files to expect
+func writeStrategies(filename string, C []strategy, confs []*strategyConfig) {
+	f, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	// replications of the same entry have the same base name
+	buf.WriteString("name,type,base,seed\n")
+
+	for i, c := range C {
+		buf.WriteString(c.getName())
+		buf.WriteString(",")
+		buf.WriteString(confs[i].kind)
+		buf.WriteString(",")
+		buf.WriteString(strings.TrimSuffix(c.getName(), confs[i].replication))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(confs[i].seed, 10))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
@@ -0,0 +1,188 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"strconv"
+)
+
+const (
+	// caches serve whatever copy they have, even if the item has changed since
+	consistencyStale = "STALE"
+	// caches ask the origin whether their copy is current before serving it, outdated copies are fetched again
+	consistencyRevalidate = "REVALIDATE"
+	// origins tell all caches with a copy when an item changes, and the caches remove it
+	consistencyInvalidate = "INVALIDATE"
+
+	// size of validation and invalidation messages in bytes
+	defaultMessageSize = 100
+)
+
+// itemVersions knows when items change
+type itemVersions struct {
+	// seconds between two versions of an item, items without an interval never change
+	intervals map[int64]int64
+}
+
+func newItemVersions(intervals *map[int64]int64) *itemVersions {
+	return &itemVersions{
+		intervals: *intervals,
+	}
+}
+
+// version of an item at a time
+// items do not all change at once, so the changes of an item are shifted by its id
+func (V *itemVersions) version(item int64, time int64) int64 {
+	interval := V.intervals[item]
+
+	if interval <= 0 {
+		return 0
+	}
+
+	return (time + item%interval) / interval
+}
+
+// cacheConsistency decides what strategies do with copies of items that have changed
+type cacheConsistency struct {
+	mode        string
+	versions    *itemVersions
+	origins     map[int64]int64
+	messageSize int64
+	// messages between satellites without a known path take the +Grid links
+	constellation *constellation
+}
+
+func getCacheConsistency(conf *strategyConfig, env *strategyEnv) *cacheConsistency {
+	S := &cacheConsistency{
+		mode:          conf.getString("consistency", consistencyStale),
+		versions:      env.itemVersions,
+		messageSize:   conf.getInt64("message_size", defaultMessageSize),
+		constellation: env.constellation,
+	}
+
+	switch S.mode {
+	case consistencyStale, consistencyRevalidate:
+	case consistencyInvalidate:
+		// only invalidation needs to know where items come from
+		S.origins = *getItemOrigins(env.loadFile, env.locationFile)
+	default:
+		panic(conf.name + ": unknown consistency " + S.mode + ", use " + consistencyStale + ", " + consistencyRevalidate + ", or " + consistencyInvalidate)
+	}
+
+	if S.messageSize < 0 {
+		panic(conf.name + ": message_size must not be negative")
+	}
+
+	return S
+}
+
+// suffix is appended to the name of a strategy so that different consistency modes can be told apart
+func (S *cacheConsistency) suffix() string {
+	if S.mode == consistencyStale {
+		return ""
+	}
+
+	return "-" + S.mode
+}
+
+// removesItems tells whether copies are taken out of the caches, which only happens with invalidation
+func (S *cacheConsistency) removesItems() bool {
+	return S.mode == consistencyInvalidate
+}
+
+// usable tells whether a copy with the given version may serve a request
+func (S *cacheConsistency) usable(version int64, req *request) bool {
+	return S.mode != consistencyRevalidate || version == req.version
+}
+
+// validate sends a validation message from the node that serves a request from its cache to the origin and back
+func (S *cacheConsistency) validate(node int64, req *request, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, txRecords *[]txRecord) {
+	if S.mode != consistencyRevalidate {
+		return
+	}
+
+	path := getPath(S.constellation, shortestSatPaths, gndSatLinks, node, req.path[len(req.path)-1])
+
+	for i := 0; i < len(path)-1; i++ {
+		*txRecords = append(*txRecords, txRecord{
+			source:    path[i],
+			target:    path[i+1],
+			bandwidth: S.messageSize,
+			kind:      txInvalidation,
+		}, txRecord{
+			source:    path[i+1],
+			target:    path[i],
+			bandwidth: S.messageSize,
+			kind:      txInvalidation,
+		})
+	}
+}
+
+// invalidate removes all outdated copies from the caches, the origin of an item sends a message to every node with a copy
+// location gives the network node of a cache, nil if caches are network nodes themselves
+// it returns the node and item of every removed copy
+func (S *cacheConsistency) invalidate(time int64, cache *nodeCaches, location func(node int64) int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, txRecords *[]txRecord) [][2]int64 {
+	var removed [][2]int64
+
+	if S.mode != consistencyInvalidate {
+		return removed
+	}
+
+	for node, n := range cache.nodes {
+		outdated := []int64{}
+
+		for item, version := range n.items {
+			if version != S.versions.version(item, time) {
+				outdated = append(outdated, item)
+			}
+		}
+
+		if len(outdated) == 0 {
+			continue
+		}
+
+		target := node
+		if location != nil {
+			target = location(node)
+		}
+
+		for _, item := range outdated {
+			origin, ok := S.origins[item]
+
+			if !ok {
+				panic("no origin for item " + strconv.FormatInt(item, 10))
+			}
+
+			path := getPath(S.constellation, shortestSatPaths, gndSatLinks, origin, target)
+
+			for i := 0; i < len(path)-1; i++ {
+				*txRecords = append(*txRecords, txRecord{
+					source:    path[i],
+					target:    path[i+1],
+					bandwidth: S.messageSize,
+					kind:      txInvalidation,
+				})
+			}
+
+			cache.remove(node, item)
+			removed = append(removed, [2]int64{node, item})
+		}
+	}
+
+	return removed
+}
@@ -0,0 +1,228 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "math"
+
+const (
+	// same constants as in the simulation
+	earthRadius                = 6371000.0
+	stdGravitationalParamEarth = 3.986004418e14
+	defaultEarthRotationPeriod = 86400.0
+	defaultPlanes              = 24
+	defaultSatsPerPlane        = 66
+	defaultInclination         = 53.0
+	defaultAltitude            = 550.0
+	defaultPhasing             = 0
+)
+
+// constellation describes the geometry of a walker delta constellation
+// satellite ids are assigned plane by plane, i.e. sat = plane * satsPerPlane + posInPlane
+type constellation struct {
+	planes       int64
+	satsPerPlane int64
+	// inclination in degrees
+	inclination float64
+	// altitude in km
+	altitude float64
+	// walker phasing factor
+	phasing int64
+	// seconds per rotation of earth
+	earthRotationPeriod float64
+}
+
+func getConstellation(c configTable) *constellation {
+	C := &constellation{
+		planes:              c.getInt64("planes", defaultPlanes),
+		satsPerPlane:        c.getInt64("sats_per_plane", defaultSatsPerPlane),
+		inclination:         c.getFloat64("inclination", defaultInclination),
+		altitude:            c.getFloat64("altitude", defaultAltitude),
+		phasing:             c.getInt64("phasing", defaultPhasing),
+		earthRotationPeriod: c.getFloat64("earth_rotation_period", defaultEarthRotationPeriod),
+	}
+
+	if C.planes <= 0 || C.satsPerPlane <= 0 {
+		panic("constellation needs at least one plane and one satellite per plane")
+	}
+
+	if C.altitude <= 0 {
+		panic("constellation altitude must be positive")
+	}
+
+	if C.earthRotationPeriod <= 0 {
+		panic("earth rotation period must be positive")
+	}
+
+	return C
+}
+
+func (C *constellation) numSats() int64 {
+	return C.planes * C.satsPerPlane
+}
+
+func (C *constellation) planeOf(sat int64) int64 {
+	return sat / C.satsPerPlane
+}
+
+func (C *constellation) posInPlane(sat int64) int64 {
+	return sat % C.satsPerPlane
+}
+
+// satAt returns the satellite at the given position, both plane and position wrap around
+func (C *constellation) satAt(plane int64, pos int64) int64 {
+	plane = ((plane % C.planes) + C.planes) % C.planes
+	pos = ((pos % C.satsPerPlane) + C.satsPerPlane) % C.satsPerPlane
+
+	return plane*C.satsPerPlane + pos
+}
+
+// intraPlanePredecessor is the satellite that follows sat in its plane
+// it will be where sat is now after one intra-plane interval
+func (C *constellation) intraPlanePredecessor(sat int64) int64 {
+	return C.satAt(C.planeOf(sat), C.posInPlane(sat)-1)
+}
+
+// gridPath is a shortest path between two satellites over the +Grid links of the constellation, i.e., the links to
+// the two neighbors in the same plane and to the satellites at the same position in the two neighboring planes
+// it first goes across planes and then along the plane, both the shorter way around
+// it returns false if one of the nodes is not a satellite of the constellation
+func (C *constellation) gridPath(from int64, to int64) ([]int64, bool) {
+	if from < 0 || to < 0 || from >= C.numSats() || to >= C.numSats() {
+		return nil, false
+	}
+
+	plane := C.planeOf(from)
+	pos := C.posInPlane(from)
+
+	planeSteps := ringSteps(plane, C.planeOf(to), C.planes)
+	posSteps := ringSteps(pos, C.posInPlane(to), C.satsPerPlane)
+
+	path := make([]int64, 0, abs(planeSteps)+abs(posSteps)+1)
+	path = append(path, from)
+
+	for ; planeSteps != 0; planeSteps -= sign(planeSteps) {
+		plane += sign(planeSteps)
+		path = append(path, C.satAt(plane, pos))
+	}
+
+	for ; posSteps != 0; posSteps -= sign(posSteps) {
+		pos += sign(posSteps)
+		path = append(path, C.satAt(plane, pos))
+	}
+
+	return path, true
+}
+
+// ringSteps is the shortest way from a to b on a ring of n nodes, negative if it goes backwards
+func ringSteps(a int64, b int64, n int64) int64 {
+	d := ((b-a)%n + n) % n
+
+	if d > n/2 {
+		d -= n
+	}
+
+	return d
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func sign(x int64) int64 {
+	if x < 0 {
+		return -1
+	}
+
+	return 1
+}
+
+// crossPlaneNeighbor is the satellite in the next plane that is closest to sat
+// with walker phasing, satellites in the next plane are ahead by phasing / planes satellites
+// when wrapping around from the last plane to the first one, that adds up to phasing satellites
+func (C *constellation) crossPlaneNeighbor(sat int64) int64 {
+	plane := C.planeOf(sat)
+	nextPlane := (plane + 1) % C.planes
+
+	shift := float64((plane-nextPlane)*C.phasing) / float64(C.planes)
+
+	return C.satAt(nextPlane, C.posInPlane(sat)+int64(math.Round(shift)))
+}
+
+// islNeighbors are the satellites that sat has an inter-satellite link to in a +GRID
+// those are the satellites in front of and behind it in its plane and the satellites at the same position in the planes next to it
+func (C *constellation) islNeighbors(sat int64) []int64 {
+	plane := C.planeOf(sat)
+	pos := C.posInPlane(sat)
+
+	return []int64{
+		C.satAt(plane, pos+1),
+		C.satAt(plane, pos-1),
+		C.satAt(plane+1, pos),
+		C.satAt(plane-1, pos),
+	}
+}
+
+// distributionTree gives the links over which an item is sent from root to all satellites, each link is used once
+// links are in the order in which the item is forwarded, from the root outwards
+func (C *constellation) distributionTree(root int64) [][2]int64 {
+	links := make([][2]int64, 0, C.numSats()-1)
+
+	reached := make([]bool, C.numSats())
+	reached[root] = true
+
+	queue := []int64{root}
+
+	for len(queue) > 0 {
+		sat := queue[0]
+		queue = queue[1:]
+
+		for _, n := range C.islNeighbors(sat) {
+			if reached[n] {
+				continue
+			}
+
+			reached[n] = true
+			links = append(links, [2]int64{sat, n})
+			queue = append(queue, n)
+		}
+	}
+
+	return links
+}
+
+// orbitalPeriod is the time for one orbit in seconds, rounded down like in the simulation
+func (C *constellation) orbitalPeriod() int64 {
+	a := earthRadius + C.altitude*1000.0
+	return int64(2.0 * math.Pi * math.Sqrt(math.Pow(a, 3)/stdGravitationalParamEarth))
+}
+
+// intraPlaneInterval is the time after which a satellite has moved to where the satellite in front of it was
+// for the first shell of starlink, that is 5730s / 66 = 87s
+func (C *constellation) intraPlaneInterval() int64 {
+	return int64(math.Round(float64(C.orbitalPeriod()) / float64(C.satsPerPlane)))
+}
+
+// crossPlaneInterval is the time after which earth has rotated underneath one plane to the next one
+// for 24 planes, that is 86400s / 24 = 3600s
+func (C *constellation) crossPlaneInterval() int64 {
+	return int64(math.Round(C.earthRotationPeriod / float64(C.planes)))
+}
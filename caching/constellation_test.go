@@ -0,0 +1,81 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// the caches of the first satellite in a plane move to the last one in the same plane
+// before, positions 0 and 1 both moved to the first satellite of the next plane
+func TestIntraPlanePredecessor(t *testing.T) {
+	C := &constellation{
+		planes:       3,
+		satsPerPlane: 4,
+	}
+
+	want := map[int64]int64{
+		0: 3, 1: 0, 2: 1, 3: 2,
+		4: 7, 5: 4, 6: 5, 7: 6,
+		8: 11, 9: 8, 10: 9, 11: 10,
+	}
+
+	for sat := int64(0); sat < C.numSats(); sat++ {
+		if got := C.intraPlanePredecessor(sat); got != want[sat] {
+			t.Errorf("intraPlanePredecessor(%d) = %d, want %d", sat, got, want[sat])
+		}
+	}
+}
+
+// paths take the +Grid links the shorter way around, first across planes and then along the plane
+func TestGridPath(t *testing.T) {
+	C := &constellation{
+		planes:       3,
+		satsPerPlane: 4,
+	}
+
+	paths := []struct {
+		from int64
+		to   int64
+		want []int64
+	}{
+		{from: 0, to: 0, want: []int64{0}},
+		{from: 0, to: 1, want: []int64{0, 1}},
+		{from: 0, to: 3, want: []int64{0, 3}},
+		// both ways around are equally long
+		{from: 0, to: 2, want: []int64{0, 1, 2}},
+		{from: 0, to: 8, want: []int64{0, 8}},
+		{from: 1, to: 10, want: []int64{1, 9, 10}},
+		{from: 7, to: 4, want: []int64{7, 4}},
+	}
+
+	for _, p := range paths {
+		got, ok := C.gridPath(p.from, p.to)
+
+		if !ok || !reflect.DeepEqual(got, p.want) {
+			t.Errorf("gridPath(%d, %d) = %v, %t, want %v", p.from, p.to, got, ok, p.want)
+		}
+	}
+
+	for _, p := range [][2]int64{{0, 12}, {-1, 0}, {3, -5}} {
+		if got, ok := C.gridPath(p[0], p[1]); ok {
+			t.Errorf("gridPath(%d, %d) = %v, want no path", p[0], p[1], got)
+		}
+	}
+}
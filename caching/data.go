@@ -25,12 +25,18 @@ type stepSet struct {
 }
 
 type writeSet struct {
-	time         int64
-	strategyName string
-	txRecords    *[]txRecord
-	storeRecords *[]storeRecord
-	cacheRecords *[]cacheRecord
-	hopsRecords  *[]hopsRecord
+	time           int64
+	strategyName   string
+	txRecords      *[]txRecord
+	storeRecords   *[]storeRecord
+	cacheRecords   *[]cacheRecord
+	hopsRecords    *[]hopsRecord
+	latencyRecords *[]latencyRecord
+	metrics        *[]metric
+	// if set, the writer sends its state over this channel instead of writing records
+	state chan<- *writerState
+	// closed by the writer once it is done after a writeSet with time -1
+	done chan<- struct{}
 }
 
 type satPath struct {
@@ -47,12 +53,31 @@ type request struct {
 	item      int64
 	bandwidth int64
 	path      []int64
+	// version of the item at the time of the request
+	version int64
+	// the path of the request crosses a satellite or link that is down
+	failed bool
 }
 
+// kinds of traffic, so that traffic that is not a direct result of a request can be told apart
+const (
+	txRequest = iota
+	txPrefetch
+	txPlacement
+	txInvalidation
+	txMigration
+)
+
+var txKinds = []string{"request", "prefetch", "placement", "invalidation", "migration"}
+
 type txRecord struct {
 	source    int64
 	target    int64
 	bandwidth int64
+	kind      int
+	// request the traffic is for, nil for traffic that is not caused by a single request
+	// such traffic is recorded in the direction of the request, the item goes the other way
+	req *request
 }
 
 type storeRecord struct {
@@ -61,11 +86,37 @@ type storeRecord struct {
 }
 
 type cacheRecord struct {
-	item    int64
+	item int64
+	// ground station that made the request
+	gst int64
+	// size of the item in bytes
+	bytes int64
+	// origin node of the item, it only serves the request if success is false
+	origin  int64
 	success bool
+	// the request was served from an outdated copy
+	stale bool
+	// the request was routed through a satellite or link that is down
+	failed bool
 }
 
 type hopsRecord struct {
 	item int64
+	gst  int64
 	hops int64
 }
+
+// latencyRecord is what the latency of a request is calculated from, see latencyModel
+type latencyRecord struct {
+	item int64
+	gst  int64
+	// distance in meters from the client to the node that served the request
+	distance int64
+	hops     int64
+}
+
+// metric is a strategy-specific value for one step, e.g., the number of lookup messages
+type metric struct {
+	name  string
+	value float64
+}
@@ -0,0 +1,162 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// energyModel is the energy the satellites spend on caching
+// ground stations and origins are connected to the grid, their energy is not counted
+type energyModel struct {
+	// joules per byte sent, per link type
+	perByte []float64
+	// joules per byte stored for one step
+	perStoredByte float64
+	constellation *constellation
+}
+
+// getEnergyModel reads the [energy] table of the workload config, it returns nil if there is none
+// transmission energy is given in joules per byte for each type of link, storage power in watts per stored gigabyte
+func getEnergyModel(workloadConfig *toml.Tree, C *constellation, stepLength int64) *energyModel {
+	if !workloadConfig.Has("energy") {
+		return nil
+	}
+
+	c := getConfigTable(workloadConfig, "energy")
+
+	E := &energyModel{
+		perByte:       make([]float64, len(linkTypes)),
+		constellation: C,
+	}
+
+	for t, name := range linkTypes {
+		E.perByte[t] = c.getFloat64(name, 0)
+
+		if E.perByte[t] < 0 {
+			panic("energy: " + name + " must not be negative")
+		}
+	}
+
+	storage := c.getFloat64("storage", 0)
+
+	if storage < 0 {
+		panic("energy: storage must not be negative")
+	}
+
+	E.perStoredByte = storage / 1e9 * float64(stepLength)
+
+	return E
+}
+
+// writeEnergy writes the following to file, all in joules
+// * total energy of all satellites
+// * max energy per sat
+// * min energy per sat
+// * avg energy per sat
+// * median energy per sat
+// * 95th pcntl energy per sat
+// * 99th pcntl energy per sat
+// * total energy for transmission
+// * total energy for storage
+// every byte is charged to the satellite that sends it, or for uplinks, to the satellite that receives it
+func (E *energyModel) writeEnergy(filename string, records *[]txRecord, strPerNode map[int64]int64) {
+	energyPerSat := make([]float64, E.constellation.numSats())
+
+	var totalTx float64
+	// bytes are summed up as integers, so the total does not depend on the order of the nodes
+	var storedBytes int64
+
+	for _, r := range *records {
+		l := direction(r)
+		t, ok := linkType(E.constellation, l[0], l[1])
+
+		if !ok {
+			continue
+		}
+
+		sat := l[0]
+		if t == linkUplink {
+			sat = l[1]
+		}
+
+		e := float64(r.bandwidth) * E.perByte[t]
+		energyPerSat[sat] += e
+		totalTx += e
+	}
+
+	for node, store := range strPerNode {
+		if node < 0 {
+			continue
+		}
+
+		energyPerSat[node] += float64(store) * E.perStoredByte
+		storedBytes += store
+	}
+
+	totalStore := float64(storedBytes) * E.perStoredByte
+
+	sorted := make([]float64, len(energyPerSat))
+	copy(sorted, energyPerSat)
+	sort.Float64s(sorted)
+
+	var total float64
+
+	for _, e := range sorted {
+		total += e
+	}
+
+	energyFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer energyFile.Close()
+
+	buf := bufio.NewWriter(energyFile)
+
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"total", total},
+		{"max", sorted[len(sorted)-1]},
+		{"min", sorted[0]},
+		{"avg", total / float64(len(sorted))},
+		{"median", percentile(sorted, 50)},
+		{"95th", percentile(sorted, 95)},
+		{"99th", percentile(sorted, 99)},
+		{"total_tx", totalTx},
+		{"total_store", totalStore},
+	}
+
+	for _, v := range values {
+		buf.WriteString(v.name)
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(v.value, 'f', -1, 64))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
@@ -0,0 +1,75 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// evictionPolicy decides which item to remove from a full node cache
+// there is one policy instance per node cache
+type evictionPolicy interface {
+	// add is called when an item is put into the cache
+	add(item int64, size int64)
+	// hit is called when an item that is in the cache is requested
+	hit(item int64)
+	// remove is called when an item leaves the cache without being evicted, e.g., when it is invalidated
+	remove(item int64)
+	// evict chooses an item in the cache, forgets about it, and returns it
+	// it is only called when the cache is not empty
+	evict() int64
+	// save writes the state of the policy to a checkpoint
+	// load restores it into a new policy for a cache of the same capacity
+	save(w *checkpointWriter)
+	load(r *checkpointReader)
+}
+
+// policyFactory creates a new eviction policy for a node cache with the given capacity in bytes
+type policyFactory func(capacity int64, rng *rand.Rand) evictionPolicy
+
+var policyFactories = make(map[string]policyFactory)
+
+// registerPolicy makes an eviction policy available for use in the workload config
+// it should be called from an init function in the file that implements the policy
+func registerPolicy(name string, factory policyFactory) {
+	if _, ok := policyFactories[name]; ok {
+		panic("eviction policy registered twice: " + name)
+	}
+
+	policyFactories[name] = factory
+}
+
+func getPolicyFactory(name string) policyFactory {
+	factory, ok := policyFactories[name]
+
+	if !ok {
+		known := make([]string, 0, len(policyFactories))
+
+		for p := range policyFactories {
+			known = append(known, p)
+		}
+
+		sort.Strings(known)
+
+		panic("Unknown eviction policy: " + name + ", use one of " + strings.Join(known, ", "))
+	}
+
+	return factory
+}
@@ -0,0 +1,374 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// traceStep is one request of a reference trace and what the cache should do with it
+type traceStep struct {
+	item int64
+	// the item was in the cache
+	hit bool
+	// items evicted when a missed item is added, in order
+	evicted []int64
+}
+
+// evictionTrace replays a request trace against a single node cache with one eviction policy
+type evictionTrace struct {
+	name     string
+	policy   string
+	capacity int64
+	// size of every item in the trace
+	size  int64
+	trace []traceStep
+	// check looks at the state of the policy after the trace
+	check func(t *testing.T, P evictionPolicy)
+}
+
+func (e evictionTrace) run(t *testing.T) {
+	sizes := make(map[int64]int64)
+
+	for _, s := range e.trace {
+		sizes[s.item] = e.size
+	}
+
+	N := newNodeCaches(cacheConfig{capacity: e.capacity, policy: e.policy}, &sizes)
+
+	for i, s := range e.trace {
+		hit := N.has(0, s.item)
+
+		var evicted []int64
+
+		if hit {
+			N.hit(0, s.item)
+		} else {
+			evicted = N.add(0, s.item, 0)
+		}
+
+		if hit != s.hit {
+			t.Fatalf("request %d for item %d: got hit %t, want %t", i, s.item, hit, s.hit)
+		}
+
+		if len(evicted) != 0 || len(s.evicted) != 0 {
+			if !reflect.DeepEqual(evicted, s.evicted) {
+				t.Fatalf("request %d for item %d: evicted %v, want %v", i, s.item, evicted, s.evicted)
+			}
+		}
+	}
+
+	if e.check != nil {
+		e.check(t, N.nodes[0].policy)
+	}
+}
+
+// arcTrace fills T2 with two items and then hits both ghost lists, capacity is four items
+var arcTrace = []traceStep{
+	{item: 1},
+	{item: 2},
+	{item: 1, hit: true},
+	{item: 2, hit: true},
+	{item: 3},
+	{item: 4},
+	// T1 is larger than p, so its oldest item goes to B1
+	{item: 5, evicted: []int64{3}},
+	// ghost hit in B1: p grows to one item and 3 goes to T2
+	{item: 3, evicted: []int64{4}},
+	{item: 6, evicted: []int64{5}},
+	// ghost hit in B1 again: p grows to two items, so T1 is small enough and T2 has to give up 1
+	{item: 4, evicted: []int64{1}},
+	// ghost hit in B2: p shrinks back to one item and T1 has to give up 6
+	{item: 1, evicted: []int64{6}},
+}
+
+func arcCheck(p float64, lists map[int64]string) func(t *testing.T, P evictionPolicy) {
+	return func(t *testing.T, P evictionPolicy) {
+		arc := P.(*arcPolicy)
+
+		if arc.p != p {
+			t.Errorf("p is %v, want %v", arc.p, p)
+		}
+
+		names := map[*arcList]string{arc.t1: "T1", arc.t2: "T2", arc.b1: "B1", arc.b2: "B2"}
+
+		for item, want := range lists {
+			if got := names[arc.lists[item]]; got != want {
+				t.Errorf("item %d is in %q, want %q", item, got, want)
+			}
+		}
+	}
+}
+
+func TestEvictionTraces(t *testing.T) {
+	traces := []evictionTrace{
+		{
+			name:     "LRU evicts the least recently used item",
+			policy:   "LRU",
+			capacity: 3,
+			size:     1,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				{item: 1, hit: true},
+				// 1 was used after 2
+				{item: 4, evicted: []int64{2}},
+				{item: 2, evicted: []int64{3}},
+				{item: 1, hit: true},
+				{item: 5, evicted: []int64{4}},
+				{item: 2, hit: true},
+			},
+		},
+		{
+			name:     "FIFO ignores hits",
+			policy:   "FIFO",
+			capacity: 3,
+			size:     1,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				{item: 1, hit: true},
+				// 1 is still the oldest item
+				{item: 4, evicted: []int64{1}},
+				{item: 1, evicted: []int64{2}},
+				{item: 5, evicted: []int64{3}},
+				{item: 4, hit: true},
+			},
+		},
+		{
+			name:     "LFU evicts the least frequently used item and breaks ties by recency",
+			policy:   "LFU",
+			capacity: 3,
+			size:     1,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				{item: 1, hit: true},
+				{item: 1, hit: true},
+				{item: 3, hit: true},
+				{item: 4, evicted: []int64{2}},
+				// 4 and 5 were both used once, 4 less recently
+				{item: 5, evicted: []int64{4}},
+				{item: 2, evicted: []int64{5}},
+				{item: 1, hit: true},
+				{item: 3, hit: true},
+			},
+			check: func(t *testing.T, P evictionPolicy) {
+				l := P.(*lfuPolicy)
+
+				for item, freq := range map[int64]int64{1: 4, 2: 1, 3: 3} {
+					if e, ok := l.elems[item]; !ok || e.freq != freq {
+						t.Errorf("item %d does not have frequency %d", item, freq)
+					}
+				}
+			},
+		},
+		{
+			name:     "ARC ghost hit in B1 grows T1",
+			policy:   "ARC",
+			capacity: 4,
+			size:     1,
+			trace:    arcTrace[:8],
+			check:    arcCheck(1, map[int64]string{3: "T2", 4: "B1", 5: "T1"}),
+		},
+		{
+			name:     "ARC T2 gives way once p has grown",
+			policy:   "ARC",
+			capacity: 4,
+			size:     1,
+			trace:    arcTrace[:10],
+			check:    arcCheck(2, map[int64]string{1: "B2", 4: "T2", 5: "B1", 6: "T1"}),
+		},
+		{
+			name:     "ARC ghost hit in B2 shrinks T1",
+			policy:   "ARC",
+			capacity: 4,
+			size:     1,
+			trace:    arcTrace,
+			check:    arcCheck(1, map[int64]string{1: "T2", 6: "B1"}),
+		},
+		{
+			name:     "S3-FIFO promotes items hit twice out of the small queue",
+			policy:   "S3-FIFO",
+			capacity: 3,
+			size:     1,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				{item: 1, hit: true},
+				{item: 1, hit: true},
+				{item: 3, hit: true},
+				// 1 moves to the main queue instead of being evicted
+				{item: 4, evicted: []int64{2}},
+				// 2 is in the ghost queue and goes straight to the main queue
+				// 3 was only hit once, which is not enough to be promoted
+				{item: 2, evicted: []int64{3}},
+				{item: 5, evicted: []int64{4}},
+				{item: 1, hit: true},
+				{item: 2, hit: true},
+			},
+			check: func(t *testing.T, P evictionPolicy) {
+				s := P.(*s3fifoPolicy)
+
+				for _, item := range []int64{1, 2} {
+					if e, ok := s.entries[item]; !ok || !e.inMain {
+						t.Errorf("item %d is not in the main queue", item)
+					}
+				}
+
+				if e, ok := s.entries[5]; !ok || e.inMain {
+					t.Errorf("item 5 is not in the small queue")
+				}
+
+				for _, item := range []int64{3, 4} {
+					if _, ok := s.ghostEntries[item]; !ok {
+						t.Errorf("item %d is not in the ghost queue", item)
+					}
+				}
+			},
+		},
+		{
+			name:     "SIEVE hand moves on from the last eviction",
+			policy:   "SIEVE",
+			capacity: 3,
+			size:     1,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				{item: 1, hit: true},
+				// the hand starts at the oldest item, passes the visited 1, and stops at 2
+				{item: 4, evicted: []int64{2}},
+				// the hand continues at 3 instead of going back to 1
+				{item: 2, evicted: []int64{3}},
+				{item: 4, hit: true},
+				// 4 gets another chance, the hand stops at 2
+				{item: 5, evicted: []int64{2}},
+				{item: 1, hit: true},
+			},
+			check: func(t *testing.T, P evictionPolicy) {
+				s := P.(*sievePolicy)
+
+				if s.hand == nil || s.hand.Value.(int64) != 5 {
+					t.Errorf("hand is at %v, want 5", s.hand)
+				}
+
+				var items []int64
+
+				for e := s.order.Back(); e != nil; e = e.Prev() {
+					items = append(items, e.Value.(int64))
+				}
+
+				if want := []int64{1, 4, 5}; !reflect.DeepEqual(items, want) {
+					t.Errorf("items are %v, want %v", items, want)
+				}
+
+				if s.visited[4] {
+					t.Errorf("the hand did not clear the visited bit of 4")
+				}
+
+				if !s.visited[1] {
+					t.Errorf("item 1 is not visited")
+				}
+			},
+		},
+		{
+			name:     "W-TinyLFU admits window items that are more popular than the main victim",
+			policy:   "W-TINYLFU",
+			capacity: 300,
+			size:     100,
+			trace: []traceStep{
+				{item: 1},
+				{item: 2},
+				{item: 3},
+				// 1 and 2 fill the main segment, 3 is not more popular than 1 and is not admitted
+				{item: 4, evicted: []int64{3}},
+				// same for 4
+				{item: 3, evicted: []int64{4}},
+				// 3 was requested twice and replaces 1
+				{item: 4, evicted: []int64{1}},
+				{item: 2, hit: true},
+				{item: 3, hit: true},
+			},
+			check: func(t *testing.T, P evictionPolicy) {
+				w := P.(*wtinylfuPolicy)
+
+				for item, segment := range map[int64]int{2: wtinylfuProtected, 3: wtinylfuProtected, 4: wtinylfuWindow} {
+					if e, ok := w.entries[item]; !ok || e.segment != segment {
+						t.Errorf("item %d is not in segment %d", item, segment)
+					}
+				}
+
+				if _, ok := w.entries[1]; ok {
+					t.Errorf("item 1 is still cached")
+				}
+			},
+		},
+	}
+
+	for _, e := range traces {
+		t.Run(e.name, e.run)
+	}
+}
+
+// randomEvictions replays a trace that keeps a RANDOM cache full and returns every eviction
+func randomEvictions(seed int64) []int64 {
+	sizes := make(map[int64]int64)
+
+	for item := int64(0); item < 20; item++ {
+		sizes[item] = 1
+	}
+
+	N := newNodeCaches(cacheConfig{capacity: 5, policy: "RANDOM", seed: seed}, &sizes)
+
+	var evicted []int64
+
+	for i := int64(0); i < 200; i++ {
+		item := (i * 7) % 20
+
+		if N.has(0, item) {
+			N.hit(0, item)
+			continue
+		}
+
+		evicted = append(evicted, N.add(0, item, 0)...)
+	}
+
+	return evicted
+}
+
+func TestRandomEvictionIsDeterministic(t *testing.T) {
+	first := randomEvictions(1)
+
+	if len(first) == 0 {
+		t.Fatal("nothing was evicted")
+	}
+
+	if second := randomEvictions(1); !reflect.DeepEqual(first, second) {
+		t.Errorf("two runs with the same seed evicted different items:\n%v\n%v", first, second)
+	}
+
+	if other := randomEvictions(2); reflect.DeepEqual(first, other) {
+		t.Errorf("runs with different seeds evicted the same items")
+	}
+}
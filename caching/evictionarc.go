@@ -0,0 +1,243 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math"
+	"math/rand"
+)
+
+// arcPolicy implements the Adaptive Replacement Cache (Megiddo and Modha, FAST '03)
+// T1 holds items that were requested once recently, T2 items that were requested at least twice
+// B1 and B2 remember items recently evicted from T1 and T2, respectively
+// a hit in B1 or B2 shifts the target size p of T1 towards recency or frequency
+// as our items have different sizes, all list sizes and p are in bytes
+type arcPolicy struct {
+	capacity int64
+	p        float64
+
+	t1, t2, b1, b2 *arcList
+
+	// where each item is
+	lists map[int64]*arcList
+	elems map[int64]*list.Element
+
+	// the last item that was added and whether it was in B2 before
+	lastAdded   int64
+	lastAddedB2 bool
+}
+
+type arcList struct {
+	l     *list.List
+	bytes int64
+}
+
+type arcEntry struct {
+	item int64
+	size int64
+}
+
+func init() {
+	registerPolicy("ARC", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newARCPolicy(capacity)
+	})
+}
+
+func newARCPolicy(capacity int64) *arcPolicy {
+	return &arcPolicy{
+		capacity:  capacity,
+		t1:        &arcList{l: list.New()},
+		t2:        &arcList{l: list.New()},
+		b1:        &arcList{l: list.New()},
+		b2:        &arcList{l: list.New()},
+		lists:     make(map[int64]*arcList),
+		elems:     make(map[int64]*list.Element),
+		lastAdded: -1,
+	}
+}
+
+func (P *arcPolicy) push(to *arcList, item int64, size int64) {
+	P.elems[item] = to.l.PushFront(arcEntry{
+		item: item,
+		size: size,
+	})
+	P.lists[item] = to
+	to.bytes += size
+}
+
+func (P *arcPolicy) pop(item int64) arcEntry {
+	from := P.lists[item]
+	e := from.l.Remove(P.elems[item]).(arcEntry)
+	from.bytes -= e.size
+
+	delete(P.lists, item)
+	delete(P.elems, item)
+
+	return e
+}
+
+func (P *arcPolicy) add(item int64, size int64) {
+	P.lastAdded = item
+	P.lastAddedB2 = false
+
+	switch P.lists[item] {
+	case P.b1:
+		// recency would have helped: grow T1
+		delta := float64(size)
+		if P.b1.bytes < P.b2.bytes {
+			delta = delta * float64(P.b2.bytes) / float64(P.b1.bytes)
+		}
+
+		P.p = math.Min(float64(P.capacity), P.p+delta)
+
+		P.pop(item)
+		P.push(P.t2, item, size)
+	case P.b2:
+		// frequency would have helped: shrink T1
+		delta := float64(size)
+		if P.b2.bytes < P.b1.bytes {
+			delta = delta * float64(P.b1.bytes) / float64(P.b2.bytes)
+		}
+
+		P.p = math.Max(0, P.p-delta)
+		P.lastAddedB2 = true
+
+		P.pop(item)
+		P.push(P.t2, item, size)
+	default:
+		P.push(P.t1, item, size)
+	}
+
+	P.trimGhosts()
+}
+
+func (P *arcPolicy) hit(item int64) {
+	switch P.lists[item] {
+	case P.t1, P.t2:
+		e := P.pop(item)
+		P.push(P.t2, item, e.size)
+	}
+}
+
+func (P *arcPolicy) remove(item int64) {
+	switch P.lists[item] {
+	case P.t1, P.t2:
+		P.pop(item)
+	}
+}
+
+// evict is the REPLACE routine of ARC
+// the item that was just added is only evicted if there is nothing else
+func (P *arcPolicy) evict() int64 {
+	from, to := P.t2, P.b2
+
+	if P.t1.l.Len() > 0 && (float64(P.t1.bytes) > P.p || (P.lastAddedB2 && float64(P.t1.bytes) == P.p) || P.t2.l.Len() == 0) {
+		from, to = P.t1, P.b1
+	}
+
+	victim := from.l.Back().Value.(arcEntry)
+
+	if victim.item == P.lastAdded && from.l.Len() == 1 {
+		if from == P.t1 && P.t2.l.Len() > 0 {
+			from, to = P.t2, P.b2
+		} else if from == P.t2 && P.t1.l.Len() > 0 {
+			from, to = P.t1, P.b1
+		}
+
+		victim = from.l.Back().Value.(arcEntry)
+	}
+
+	P.pop(victim.item)
+	P.push(to, victim.item, victim.size)
+
+	P.trimGhosts()
+
+	return victim.item
+}
+
+// trimGhosts keeps T1 and B1 within the capacity and the whole directory within twice the capacity
+func (P *arcPolicy) trimGhosts() {
+	for P.b1.l.Len() > 0 && P.t1.bytes+P.b1.bytes > P.capacity {
+		P.pop(P.b1.l.Back().Value.(arcEntry).item)
+	}
+
+	for P.b2.l.Len() > 0 && P.t1.bytes+P.t2.bytes+P.b1.bytes+P.b2.bytes > 2*P.capacity {
+		P.pop(P.b2.l.Back().Value.(arcEntry).item)
+	}
+}
+
+// arcEntryState is how an item in one of the lists is stored in a checkpoint
+type arcEntryState struct {
+	Item int64
+	Size int64
+}
+
+// arcState has all lists from the oldest to the newest item
+type arcState struct {
+	P           float64
+	LastAdded   int64
+	LastAddedB2 bool
+	T1, T2      []arcEntryState
+	B1, B2      []arcEntryState
+}
+
+func (l *arcList) state() []arcEntryState {
+	entries := make([]arcEntryState, 0, l.l.Len())
+
+	for e := l.l.Back(); e != nil; e = e.Prev() {
+		a := e.Value.(arcEntry)
+
+		entries = append(entries, arcEntryState{
+			Item: a.item,
+			Size: a.size,
+		})
+	}
+
+	return entries
+}
+
+func (P *arcPolicy) save(w *checkpointWriter) {
+	w.write(arcState{
+		P:           P.p,
+		LastAdded:   P.lastAdded,
+		LastAddedB2: P.lastAddedB2,
+		T1:          P.t1.state(),
+		T2:          P.t2.state(),
+		B1:          P.b1.state(),
+		B2:          P.b2.state(),
+	})
+}
+
+func (P *arcPolicy) load(r *checkpointReader) {
+	var s arcState
+	r.read(&s)
+
+	P.p = s.P
+	P.lastAdded = s.LastAdded
+	P.lastAddedB2 = s.LastAddedB2
+
+	for _, l := range []struct {
+		to      *arcList
+		entries []arcEntryState
+	}{{P.t1, s.T1}, {P.t2, s.T2}, {P.b1, s.B1}, {P.b2, s.B2}} {
+		for _, e := range l.entries {
+			P.push(l.to, e.Item, e.Size)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+// fifoPolicy evicts the item that has been in the cache the longest, hits do not matter
+type fifoPolicy struct {
+	order *list.List
+	elems map[int64]*list.Element
+}
+
+func init() {
+	registerPolicy("FIFO", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newFIFOPolicy()
+	})
+}
+
+func newFIFOPolicy() *fifoPolicy {
+	return &fifoPolicy{
+		order: list.New(),
+		elems: make(map[int64]*list.Element),
+	}
+}
+
+func (P *fifoPolicy) add(item int64, size int64) {
+	P.elems[item] = P.order.PushFront(item)
+}
+
+func (P *fifoPolicy) hit(item int64) {}
+
+func (P *fifoPolicy) remove(item int64) {
+	if e, ok := P.elems[item]; ok {
+		P.order.Remove(e)
+		delete(P.elems, item)
+	}
+}
+
+func (P *fifoPolicy) evict() int64 {
+	item := P.order.Remove(P.order.Back()).(int64)
+	delete(P.elems, item)
+	return item
+}
+
+func (P *fifoPolicy) save(w *checkpointWriter) {
+	w.write(listItems(P.order))
+}
+
+func (P *fifoPolicy) load(r *checkpointReader) {
+	var items []int64
+	r.read(&items)
+
+	for _, item := range items {
+		P.add(item, 0)
+	}
+}
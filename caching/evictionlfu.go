@@ -0,0 +1,170 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/heap"
+	"math/rand"
+)
+
+// lfuPolicy evicts the item with the fewest hits since it was added
+// ties are broken by evicting the least recently used item
+type lfuPolicy struct {
+	entries lfuHeap
+	elems   map[int64]*lfuEntry
+	clock   int64
+}
+
+type lfuEntry struct {
+	item     int64
+	freq     int64
+	lastUsed int64
+	index    int
+}
+
+type lfuHeap []*lfuEntry
+
+func init() {
+	registerPolicy("LFU", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newLFUPolicy()
+	})
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{
+		entries: lfuHeap{},
+		elems:   make(map[int64]*lfuEntry),
+	}
+}
+
+func (P *lfuPolicy) add(item int64, size int64) {
+	P.clock++
+
+	e := &lfuEntry{
+		item:     item,
+		freq:     1,
+		lastUsed: P.clock,
+	}
+
+	P.elems[item] = e
+	heap.Push(&P.entries, e)
+}
+
+func (P *lfuPolicy) hit(item int64) {
+	e, ok := P.elems[item]
+
+	if !ok {
+		return
+	}
+
+	P.clock++
+	e.freq++
+	e.lastUsed = P.clock
+	heap.Fix(&P.entries, e.index)
+}
+
+func (P *lfuPolicy) remove(item int64) {
+	e, ok := P.elems[item]
+
+	if !ok {
+		return
+	}
+
+	heap.Remove(&P.entries, e.index)
+	delete(P.elems, item)
+}
+
+func (P *lfuPolicy) evict() int64 {
+	e := heap.Pop(&P.entries).(*lfuEntry)
+	delete(P.elems, e.item)
+	return e.item
+}
+
+func (h lfuHeap) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].lastUsed < h[j].lastUsed
+	}
+
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// lfuEntryState is how an entry is stored in a checkpoint
+type lfuEntryState struct {
+	Item     int64
+	Freq     int64
+	LastUsed int64
+}
+
+// entries are saved in the order of the heap, so that ties are broken the same way after loading
+func (P *lfuPolicy) save(w *checkpointWriter) {
+	entries := make([]lfuEntryState, len(P.entries))
+
+	for i, e := range P.entries {
+		entries[i] = lfuEntryState{
+			Item:     e.item,
+			Freq:     e.freq,
+			LastUsed: e.lastUsed,
+		}
+	}
+
+	w.write(P.clock)
+	w.write(entries)
+}
+
+func (P *lfuPolicy) load(r *checkpointReader) {
+	var entries []lfuEntryState
+	r.read(&P.clock)
+	r.read(&entries)
+
+	for i, s := range entries {
+		e := &lfuEntry{
+			item:     s.Item,
+			freq:     s.Freq,
+			lastUsed: s.LastUsed,
+			index:    i,
+		}
+
+		P.elems[e.item] = e
+		P.entries = append(P.entries, e)
+	}
+}
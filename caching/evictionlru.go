@@ -0,0 +1,78 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+// lruPolicy evicts the least recently used item
+type lruPolicy struct {
+	order *list.List
+	elems map[int64]*list.Element
+}
+
+func init() {
+	registerPolicy("LRU", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newLRUPolicy()
+	})
+}
+
+func newLRUPolicy() *lruPolicy {
+	return &lruPolicy{
+		order: list.New(),
+		elems: make(map[int64]*list.Element),
+	}
+}
+
+func (P *lruPolicy) add(item int64, size int64) {
+	P.elems[item] = P.order.PushFront(item)
+}
+
+func (P *lruPolicy) hit(item int64) {
+	if e, ok := P.elems[item]; ok {
+		P.order.MoveToFront(e)
+	}
+}
+
+func (P *lruPolicy) remove(item int64) {
+	if e, ok := P.elems[item]; ok {
+		P.order.Remove(e)
+		delete(P.elems, item)
+	}
+}
+
+func (P *lruPolicy) evict() int64 {
+	item := P.order.Remove(P.order.Back()).(int64)
+	delete(P.elems, item)
+	return item
+}
+
+func (P *lruPolicy) save(w *checkpointWriter) {
+	w.write(listItems(P.order))
+}
+
+func (P *lruPolicy) load(r *checkpointReader) {
+	var items []int64
+	r.read(&items)
+
+	for _, item := range items {
+		P.add(item, 0)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "math/rand"
+
+// randomPolicy evicts a random item
+type randomPolicy struct {
+	rng   *rand.Rand
+	items []int64
+	index map[int64]int
+}
+
+func init() {
+	registerPolicy("RANDOM", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newRandomPolicy(rng)
+	})
+}
+
+func newRandomPolicy(rng *rand.Rand) *randomPolicy {
+	return &randomPolicy{
+		rng:   rng,
+		items: []int64{},
+		index: make(map[int64]int),
+	}
+}
+
+func (P *randomPolicy) add(item int64, size int64) {
+	P.index[item] = len(P.items)
+	P.items = append(P.items, item)
+}
+
+func (P *randomPolicy) hit(item int64) {}
+
+func (P *randomPolicy) remove(item int64) {
+	i, ok := P.index[item]
+
+	if !ok {
+		return
+	}
+
+	// move the last item into the gap
+	last := P.items[len(P.items)-1]
+	P.items[i] = last
+	P.index[last] = i
+
+	P.items = P.items[:len(P.items)-1]
+	delete(P.index, item)
+}
+
+func (P *randomPolicy) evict() int64 {
+	item := P.items[P.rng.Intn(len(P.items))]
+	P.remove(item)
+	return item
+}
+
+// the random number generator belongs to the node caches, which save it themselves
+func (P *randomPolicy) save(w *checkpointWriter) {
+	w.write(P.items)
+}
+
+func (P *randomPolicy) load(r *checkpointReader) {
+	var items []int64
+	r.read(&items)
+
+	for _, item := range items {
+		P.add(item, 0)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+const (
+	// share of the capacity for the small queue
+	s3fifoSmallShare = 0.1
+	// frequencies are capped at this value
+	s3fifoMaxFreq = 3
+)
+
+// s3fifoPolicy implements S3-FIFO (Yang et al., SOSP '23)
+// new items go into a small FIFO queue, items that are hit more than once while in there move to a main FIFO queue
+// items evicted from the small queue are remembered in a ghost queue and go straight to the main queue if they come back
+// the main queue gives items that were hit another round before they are evicted
+type s3fifoPolicy struct {
+	capacity int64
+
+	small      *list.List
+	main       *list.List
+	ghost      *list.List
+	smallBytes int64
+	mainBytes  int64
+	ghostBytes int64
+
+	entries      map[int64]*s3fifoEntry
+	ghostEntries map[int64]*list.Element
+}
+
+type s3fifoEntry struct {
+	size   int64
+	freq   int64
+	inMain bool
+	elem   *list.Element
+}
+
+type s3fifoGhost struct {
+	item int64
+	size int64
+}
+
+func init() {
+	registerPolicy("S3-FIFO", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newS3FIFOPolicy(capacity)
+	})
+}
+
+func newS3FIFOPolicy(capacity int64) *s3fifoPolicy {
+	return &s3fifoPolicy{
+		capacity:     capacity,
+		small:        list.New(),
+		main:         list.New(),
+		ghost:        list.New(),
+		entries:      make(map[int64]*s3fifoEntry),
+		ghostEntries: make(map[int64]*list.Element),
+	}
+}
+
+func (P *s3fifoPolicy) add(item int64, size int64) {
+	e := &s3fifoEntry{
+		size: size,
+	}
+
+	if g, ok := P.ghostEntries[item]; ok {
+		P.removeGhost(g)
+
+		e.inMain = true
+		e.elem = P.main.PushFront(item)
+		P.mainBytes += size
+	} else {
+		e.elem = P.small.PushFront(item)
+		P.smallBytes += size
+	}
+
+	P.entries[item] = e
+}
+
+func (P *s3fifoPolicy) hit(item int64) {
+	if e, ok := P.entries[item]; ok && e.freq < s3fifoMaxFreq {
+		e.freq++
+	}
+}
+
+func (P *s3fifoPolicy) remove(item int64) {
+	e, ok := P.entries[item]
+
+	if !ok {
+		return
+	}
+
+	if e.inMain {
+		P.main.Remove(e.elem)
+		P.mainBytes -= e.size
+	} else {
+		P.small.Remove(e.elem)
+		P.smallBytes -= e.size
+	}
+
+	delete(P.entries, item)
+}
+
+func (P *s3fifoPolicy) evict() int64 {
+	for {
+		if P.main.Len() == 0 || (P.small.Len() > 0 && float64(P.smallBytes) >= s3fifoSmallShare*float64(P.capacity)) {
+			if item, ok := P.evictSmall(); ok {
+				return item
+			}
+
+			continue
+		}
+
+		if item, ok := P.evictMain(); ok {
+			return item
+		}
+	}
+}
+
+// evictSmall looks at the oldest item in the small queue
+// it is either promoted to the main queue or evicted and remembered in the ghost queue
+func (P *s3fifoPolicy) evictSmall() (int64, bool) {
+	item := P.small.Remove(P.small.Back()).(int64)
+	e := P.entries[item]
+	P.smallBytes -= e.size
+
+	if e.freq > 1 {
+		e.freq = 0
+		e.inMain = true
+		e.elem = P.main.PushFront(item)
+		P.mainBytes += e.size
+		return 0, false
+	}
+
+	delete(P.entries, item)
+	P.addGhost(item, e.size)
+
+	return item, true
+}
+
+// evictMain looks at the oldest item in the main queue
+// items that were hit get reinserted with a lower frequency
+func (P *s3fifoPolicy) evictMain() (int64, bool) {
+	item := P.main.Remove(P.main.Back()).(int64)
+	e := P.entries[item]
+
+	if e.freq > 0 {
+		e.freq--
+		e.elem = P.main.PushFront(item)
+		return 0, false
+	}
+
+	P.mainBytes -= e.size
+	delete(P.entries, item)
+
+	return item, true
+}
+
+// the ghost queue remembers as many bytes as fit into the main queue
+func (P *s3fifoPolicy) addGhost(item int64, size int64) {
+	P.ghostEntries[item] = P.ghost.PushFront(s3fifoGhost{
+		item: item,
+		size: size,
+	})
+	P.ghostBytes += size
+
+	for float64(P.ghostBytes) > (1-s3fifoSmallShare)*float64(P.capacity) {
+		P.removeGhost(P.ghost.Back())
+	}
+}
+
+func (P *s3fifoPolicy) removeGhost(g *list.Element) {
+	ghost := P.ghost.Remove(g).(s3fifoGhost)
+	P.ghostBytes -= ghost.size
+	delete(P.ghostEntries, ghost.item)
+}
+
+// s3fifoEntryState is how an item in one of the queues is stored in a checkpoint
+type s3fifoEntryState struct {
+	Item int64
+	Size int64
+	Freq int64
+}
+
+// s3fifoState has all queues from the oldest to the newest item
+type s3fifoState struct {
+	Small []s3fifoEntryState
+	Main  []s3fifoEntryState
+	Ghost []s3fifoEntryState
+}
+
+func (P *s3fifoPolicy) queueState(l *list.List) []s3fifoEntryState {
+	entries := make([]s3fifoEntryState, 0, l.Len())
+
+	for _, item := range listItems(l) {
+		e := P.entries[item]
+
+		entries = append(entries, s3fifoEntryState{
+			Item: item,
+			Size: e.size,
+			Freq: e.freq,
+		})
+	}
+
+	return entries
+}
+
+func (P *s3fifoPolicy) save(w *checkpointWriter) {
+	s := s3fifoState{
+		Small: P.queueState(P.small),
+		Main:  P.queueState(P.main),
+	}
+
+	for g := P.ghost.Back(); g != nil; g = g.Prev() {
+		ghost := g.Value.(s3fifoGhost)
+
+		s.Ghost = append(s.Ghost, s3fifoEntryState{
+			Item: ghost.item,
+			Size: ghost.size,
+		})
+	}
+
+	w.write(s)
+}
+
+func (P *s3fifoPolicy) load(r *checkpointReader) {
+	var s s3fifoState
+	r.read(&s)
+
+	for _, e := range s.Small {
+		P.entries[e.Item] = &s3fifoEntry{
+			size: e.Size,
+			freq: e.Freq,
+			elem: P.small.PushFront(e.Item),
+		}
+		P.smallBytes += e.Size
+	}
+
+	for _, e := range s.Main {
+		P.entries[e.Item] = &s3fifoEntry{
+			size:   e.Size,
+			freq:   e.Freq,
+			inMain: true,
+			elem:   P.main.PushFront(e.Item),
+		}
+		P.mainBytes += e.Size
+	}
+
+	// the ghost queue was within its bounds when it was saved, so there is no need to go through addGhost
+	for _, g := range s.Ghost {
+		P.ghostEntries[g.Item] = P.ghost.PushFront(s3fifoGhost{
+			item: g.Item,
+			size: g.Size,
+		})
+		P.ghostBytes += g.Size
+	}
+}
@@ -0,0 +1,145 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+// sievePolicy implements SIEVE (Zhang et al., NSDI '24)
+// items are kept in insertion order and a hand moves from old to new items
+// items that were hit since the hand last passed them get another chance, the first other item is evicted
+type sievePolicy struct {
+	order   *list.List
+	elems   map[int64]*list.Element
+	visited map[int64]bool
+	hand    *list.Element
+}
+
+func init() {
+	registerPolicy("SIEVE", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newSIEVEPolicy()
+	})
+}
+
+func newSIEVEPolicy() *sievePolicy {
+	return &sievePolicy{
+		order:   list.New(),
+		elems:   make(map[int64]*list.Element),
+		visited: make(map[int64]bool),
+	}
+}
+
+func (P *sievePolicy) add(item int64, size int64) {
+	P.elems[item] = P.order.PushFront(item)
+	P.visited[item] = false
+}
+
+func (P *sievePolicy) hit(item int64) {
+	if _, ok := P.elems[item]; ok {
+		P.visited[item] = true
+	}
+}
+
+func (P *sievePolicy) remove(item int64) {
+	e, ok := P.elems[item]
+
+	if !ok {
+		return
+	}
+
+	if P.hand == e {
+		P.hand = e.Prev()
+	}
+
+	P.order.Remove(e)
+	delete(P.elems, item)
+	delete(P.visited, item)
+}
+
+func (P *sievePolicy) evict() int64 {
+	e := P.hand
+
+	if e == nil {
+		e = P.order.Back()
+	}
+
+	for P.visited[e.Value.(int64)] {
+		P.visited[e.Value.(int64)] = false
+
+		e = e.Prev()
+
+		// wrap around to the oldest item
+		if e == nil {
+			e = P.order.Back()
+		}
+	}
+
+	item := e.Value.(int64)
+
+	P.hand = e.Prev()
+	P.order.Remove(e)
+	delete(P.elems, item)
+	delete(P.visited, item)
+
+	return item
+}
+
+// sieveState is how the policy is stored in a checkpoint
+type sieveState struct {
+	// from the oldest to the newest item
+	Items   []int64
+	Visited []bool
+	// position of the hand in Items, -1 if it is not set
+	Hand int
+}
+
+func (P *sievePolicy) save(w *checkpointWriter) {
+	s := sieveState{
+		Items: listItems(P.order),
+		Hand:  -1,
+	}
+
+	i := 0
+
+	for e := P.order.Back(); e != nil; e = e.Prev() {
+		if e == P.hand {
+			s.Hand = i
+		}
+
+		s.Visited = append(s.Visited, P.visited[e.Value.(int64)])
+		i++
+	}
+
+	w.write(s)
+}
+
+func (P *sievePolicy) load(r *checkpointReader) {
+	var s sieveState
+	r.read(&s)
+
+	for i, item := range s.Items {
+		P.add(item, 0)
+		P.visited[item] = s.Visited[i]
+
+		if i == s.Hand {
+			P.hand = P.elems[item]
+		}
+	}
+}
@@ -0,0 +1,350 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"container/list"
+	"math/rand"
+)
+
+const (
+	// share of the capacity for the admission window
+	wtinylfuWindowShare = 0.01
+	// share of the main space for the protected segment
+	wtinylfuProtectedShare = 0.8
+	// the sketch is sized for this average item size, as we do not know how many items will fit into a cache
+	wtinylfuAssumedItemSize = 10000
+	// sketch counters are capped at this value
+	wtinylfuMaxCount = 15
+	// number of rows in the sketch
+	wtinylfuDepth = 4
+)
+
+// wtinylfuPolicy implements W-TinyLFU (Einziger et al., ACM ToS 2017)
+// new items go into a small LRU window, items leaving the window have to compete with the
+// eviction victim of the main segmented LRU for admission
+// the item that was requested more often according to a count-min sketch stays
+type wtinylfuPolicy struct {
+	windowCapacity    int64
+	mainCapacity      int64
+	protectedCapacity int64
+
+	window    *list.List
+	probation *list.List
+	protected *list.List
+
+	windowBytes    int64
+	probationBytes int64
+	protectedBytes int64
+
+	entries map[int64]*wtinylfuEntry
+
+	sketch *countMinSketch
+}
+
+const (
+	wtinylfuWindow = iota
+	wtinylfuProbation
+	wtinylfuProtected
+)
+
+type wtinylfuEntry struct {
+	size    int64
+	segment int
+	elem    *list.Element
+}
+
+func init() {
+	registerPolicy("W-TINYLFU", func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return newWTinyLFUPolicy(capacity)
+	})
+}
+
+func newWTinyLFUPolicy(capacity int64) *wtinylfuPolicy {
+	windowCapacity := int64(wtinylfuWindowShare * float64(capacity))
+	mainCapacity := capacity - windowCapacity
+
+	return &wtinylfuPolicy{
+		windowCapacity:    windowCapacity,
+		mainCapacity:      mainCapacity,
+		protectedCapacity: int64(wtinylfuProtectedShare * float64(mainCapacity)),
+		window:            list.New(),
+		probation:         list.New(),
+		protected:         list.New(),
+		entries:           make(map[int64]*wtinylfuEntry),
+		sketch:            newCountMinSketch(capacity/wtinylfuAssumedItemSize + 1),
+	}
+}
+
+func (P *wtinylfuPolicy) segment(segment int) (*list.List, *int64) {
+	switch segment {
+	case wtinylfuWindow:
+		return P.window, &P.windowBytes
+	case wtinylfuProbation:
+		return P.probation, &P.probationBytes
+	}
+
+	return P.protected, &P.protectedBytes
+}
+
+func (P *wtinylfuPolicy) push(item int64, e *wtinylfuEntry, segment int) {
+	l, bytes := P.segment(segment)
+	e.segment = segment
+	e.elem = l.PushFront(item)
+	*bytes += e.size
+}
+
+func (P *wtinylfuPolicy) unlink(item int64, e *wtinylfuEntry) {
+	l, bytes := P.segment(e.segment)
+	l.Remove(e.elem)
+	*bytes -= e.size
+}
+
+func (P *wtinylfuPolicy) add(item int64, size int64) {
+	P.sketch.increment(item)
+
+	e := &wtinylfuEntry{
+		size: size,
+	}
+
+	P.entries[item] = e
+	P.push(item, e, wtinylfuWindow)
+}
+
+func (P *wtinylfuPolicy) hit(item int64) {
+	P.sketch.increment(item)
+
+	e, ok := P.entries[item]
+
+	if !ok {
+		return
+	}
+
+	P.unlink(item, e)
+
+	if e.segment == wtinylfuWindow {
+		P.push(item, e, wtinylfuWindow)
+		return
+	}
+
+	P.push(item, e, wtinylfuProtected)
+
+	// demote items from the protected segment if it has become too large
+	for P.protectedBytes > P.protectedCapacity && P.protected.Len() > 1 {
+		demoted := P.protected.Back().Value.(int64)
+		d := P.entries[demoted]
+		P.unlink(demoted, d)
+		P.push(demoted, d, wtinylfuProbation)
+	}
+}
+
+func (P *wtinylfuPolicy) remove(item int64) {
+	e, ok := P.entries[item]
+
+	if !ok {
+		return
+	}
+
+	P.unlink(item, e)
+	delete(P.entries, item)
+}
+
+// mainVictim is the item the main segment would evict next
+func (P *wtinylfuPolicy) mainVictim() (int64, bool) {
+	if P.probation.Len() > 0 {
+		return P.probation.Back().Value.(int64), true
+	}
+
+	if P.protected.Len() > 0 {
+		return P.protected.Back().Value.(int64), true
+	}
+
+	return 0, false
+}
+
+func (P *wtinylfuPolicy) evict() int64 {
+	for {
+		// the window is within its bounds, so the main segment has to make room
+		if P.windowBytes <= P.windowCapacity || P.window.Len() == 0 {
+			victim, ok := P.mainVictim()
+
+			if !ok {
+				victim = P.window.Back().Value.(int64)
+			}
+
+			P.remove(victim)
+			return victim
+		}
+
+		// the oldest item in the window is a candidate for the main segment
+		candidate := P.window.Back().Value.(int64)
+		c := P.entries[candidate]
+
+		// there is still space in the main segment
+		if P.probationBytes+P.protectedBytes+c.size <= P.mainCapacity {
+			P.unlink(candidate, c)
+			P.push(candidate, c, wtinylfuProbation)
+			continue
+		}
+
+		victim, ok := P.mainVictim()
+
+		// the candidate is evicted if it is not more popular than the main victim
+		if !ok || P.sketch.estimate(candidate) <= P.sketch.estimate(victim) {
+			P.remove(candidate)
+			return candidate
+		}
+
+		P.remove(victim)
+		P.unlink(candidate, c)
+		P.push(candidate, c, wtinylfuProbation)
+
+		return victim
+	}
+}
+
+// countMinSketch estimates how often items were requested, with counters that are halved periodically
+type countMinSketch struct {
+	width      uint64
+	rows       [wtinylfuDepth][]uint8
+	additions  int64
+	sampleSize int64
+}
+
+func newCountMinSketch(expectedItems int64) *countMinSketch {
+	width := uint64(64)
+
+	for width < uint64(expectedItems) {
+		width *= 2
+	}
+
+	s := &countMinSketch{
+		width:      width,
+		sampleSize: 10 * int64(width),
+	}
+
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+
+	return s
+}
+
+// index hashes an item for one row of the sketch
+func (s *countMinSketch) index(item int64, row int) uint64 {
+	// a different seed per row
+	return splitmix64(uint64(item)+uint64(row+1)*0x9e3779b97f4a7c15) & (s.width - 1)
+}
+
+func (s *countMinSketch) increment(item int64) {
+	for row := range s.rows {
+		i := s.index(item, row)
+
+		if s.rows[row][i] < wtinylfuMaxCount {
+			s.rows[row][i]++
+		}
+	}
+
+	s.additions++
+
+	// aging: halve all counters so that old popularity fades
+	if s.additions >= s.sampleSize {
+		for row := range s.rows {
+			for i := range s.rows[row] {
+				s.rows[row][i] /= 2
+			}
+		}
+
+		s.additions /= 2
+	}
+}
+
+func (s *countMinSketch) estimate(item int64) uint8 {
+	est := uint8(wtinylfuMaxCount)
+
+	for row := range s.rows {
+		if c := s.rows[row][s.index(item, row)]; c < est {
+			est = c
+		}
+	}
+
+	return est
+}
+
+// wtinylfuEntryState is how an item in one of the segments is stored in a checkpoint
+type wtinylfuEntryState struct {
+	Item int64
+	Size int64
+}
+
+// wtinylfuState has all segments from the oldest to the newest item
+type wtinylfuState struct {
+	Window    []wtinylfuEntryState
+	Probation []wtinylfuEntryState
+	Protected []wtinylfuEntryState
+
+	Rows      [wtinylfuDepth][]uint8
+	Additions int64
+}
+
+func (P *wtinylfuPolicy) segmentState(segment int) []wtinylfuEntryState {
+	l, _ := P.segment(segment)
+
+	entries := make([]wtinylfuEntryState, 0, l.Len())
+
+	for _, item := range listItems(l) {
+		entries = append(entries, wtinylfuEntryState{
+			Item: item,
+			Size: P.entries[item].size,
+		})
+	}
+
+	return entries
+}
+
+func (P *wtinylfuPolicy) save(w *checkpointWriter) {
+	w.write(wtinylfuState{
+		Window:    P.segmentState(wtinylfuWindow),
+		Probation: P.segmentState(wtinylfuProbation),
+		Protected: P.segmentState(wtinylfuProtected),
+		Rows:      P.sketch.rows,
+		Additions: P.sketch.additions,
+	})
+}
+
+func (P *wtinylfuPolicy) load(r *checkpointReader) {
+	var s wtinylfuState
+	r.read(&s)
+
+	for segment, entries := range [][]wtinylfuEntryState{s.Window, s.Probation, s.Protected} {
+		for _, e := range entries {
+			entry := &wtinylfuEntry{
+				size: e.Size,
+			}
+
+			P.entries[e.Item] = entry
+			P.push(e.Item, entry, segment)
+		}
+	}
+
+	for row := range P.sketch.rows {
+		copy(P.sketch.rows[row], s.Rows[row])
+	}
+
+	P.sketch.additions = s.Additions
+}
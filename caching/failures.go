@@ -0,0 +1,258 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// outage is a time in which a satellite or a link is down, from start (inclusive) to end (exclusive) in seconds
+type outage struct {
+	start int64
+	end   int64
+}
+
+// failureSchedule lists when which satellites and links are down
+// links are given by the nodes at both ends, the lower one first
+type failureSchedule struct {
+	nodes map[int64][]outage
+	links map[[2]int64][]outage
+}
+
+// failureState is what is down in one step
+type failureState struct {
+	nodes map[int64]struct{}
+	links map[[2]int64]struct{}
+}
+
+// getFailures reads the [failures] table of the workload config, it returns nil if there is none
+// outages are read from a scenario file, drawn at random from a mean time between failures and a mean time to repair, or both
+func getFailures(workloadConfig *toml.Tree, workloadFolder string, C *constellation, steps int64, stepLength int64) *failureSchedule {
+	if !workloadConfig.Has("failures") {
+		return nil
+	}
+
+	c := getConfigTable(workloadConfig, "failures")
+	w := configTable{
+		name: "workload",
+		tree: workloadConfig,
+	}
+
+	F := &failureSchedule{
+		nodes: make(map[int64][]outage),
+		links: make(map[[2]int64][]outage),
+	}
+
+	if c.has("file") {
+		F.readScenario(path.Join(workloadFolder, c.getString("file", "")))
+	}
+
+	mtbf := c.getFloat64("mtbf", 0)
+	mttr := c.getFloat64("mttr", 0)
+	linkMTBF := c.getFloat64("link_mtbf", 0)
+	linkMTTR := c.getFloat64("link_mttr", 0)
+
+	if mtbf < 0 || mttr < 0 || linkMTBF < 0 || linkMTTR < 0 {
+		panic("failures: mtbf, mttr, link_mtbf, and link_mttr must not be negative")
+	}
+
+	// all strategies see the same failures, and so does every replication
+	rng := rand.New(rand.NewSource(deriveSeed(w.getInt64("seed", 0), "failures")))
+	end := steps * stepLength
+
+	if mtbf > 0 {
+		for sat := int64(0); sat < C.numSats(); sat++ {
+			F.nodes[sat] = append(F.nodes[sat], drawOutages(rng, mtbf, mttr, end)...)
+		}
+	}
+
+	if linkMTBF > 0 {
+		for sat := int64(0); sat < C.numSats(); sat++ {
+			for _, n := range C.islNeighbors(sat) {
+				// every link once
+				if n <= sat {
+					continue
+				}
+
+				l := [2]int64{sat, n}
+				F.links[l] = append(F.links[l], drawOutages(rng, linkMTBF, linkMTTR, end)...)
+			}
+		}
+	}
+
+	return F
+}
+
+// drawOutages alternates exponentially distributed times up and down until the end of the run
+// a repair time of 0 means that a failed node never comes back
+func drawOutages(rng *rand.Rand, mtbf float64, mttr float64, end int64) []outage {
+	var outages []outage
+
+	t := 0.0
+
+	for {
+		t += rng.ExpFloat64() * mtbf
+
+		if t >= float64(end) {
+			return outages
+		}
+
+		if mttr == 0 {
+			return append(outages, outage{start: int64(t), end: end})
+		}
+
+		down := rng.ExpFloat64() * mttr
+		outages = append(outages, outage{start: int64(t), end: int64(t + down)})
+
+		t += down
+	}
+}
+
+// readScenario reads outages from a csv file with the columns type,node_1,node_2,start,end
+// type is either sat, then node_2 is empty, or link
+func (F *failureSchedule) readScenario(scenarioFile string) {
+	f, err := os.Open(scenarioFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	csvr := csv.NewReader(f)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		a, err := strconv.ParseInt(line[1], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		start, err := strconv.ParseInt(line[3], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		end, err := strconv.ParseInt(line[4], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		if end <= start {
+			panic("failures: outage of " + line[0] + " " + line[1] + " ends before it starts")
+		}
+
+		o := outage{start: start, end: end}
+
+		switch line[0] {
+		case "sat":
+			F.nodes[a] = append(F.nodes[a], o)
+		case "link":
+			b, err := strconv.ParseInt(line[2], 10, 64)
+
+			if err != nil {
+				panic(err)
+			}
+
+			l := linkOf(a, b)
+			F.links[l] = append(F.links[l], o)
+		default:
+			panic("failures: unknown type " + line[0] + ", use sat or link")
+		}
+	}
+}
+
+func linkOf(a int64, b int64) [2]int64 {
+	if a > b {
+		return [2]int64{b, a}
+	}
+
+	return [2]int64{a, b}
+}
+
+func down(outages []outage, time int64) bool {
+	for _, o := range outages {
+		if o.start <= time && time < o.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// at returns the satellites and links that are down at a given time
+func (F *failureSchedule) at(time int64) *failureState {
+	s := &failureState{
+		nodes: make(map[int64]struct{}),
+		links: make(map[[2]int64]struct{}),
+	}
+
+	for node, outages := range F.nodes {
+		if down(outages, time) {
+			s.nodes[node] = struct{}{}
+		}
+	}
+
+	for l, outages := range F.links {
+		if down(outages, time) {
+			s.links[l] = struct{}{}
+		}
+	}
+
+	return s
+}
+
+// flag marks the requests whose path crosses a node or link that is down
+// they are still served as if nothing happened, but the writer can tell them apart
+func (s *failureState) flag(requests *[]*request) {
+	for _, req := range *requests {
+		for i, node := range req.path {
+			if _, ok := s.nodes[node]; ok {
+				req.failed = true
+				break
+			}
+
+			if i == 0 {
+				continue
+			}
+
+			if _, ok := s.links[linkOf(req.path[i-1], node)]; ok {
+				req.failed = true
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+)
+
+// getItemOrigins finds the ground node id of the origin of each item
+// the load file only has the name of the origin, ids are given by the position in the locations file, starting with -1
+func getItemOrigins(loadFile string, locationFile string) *map[int64]int64 {
+	ids := make(map[string]int64)
+
+	locations, err := os.Open(locationFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer locations.Close()
+
+	csvr := csv.NewReader(locations)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	var id int64 = -1
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		ids[line[0]] = id
+		id--
+	}
+
+	itemOrigins := make(map[int64]int64)
+
+	load, err := os.Open(loadFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer load.Close()
+
+	csvr = csv.NewReader(load)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		item, err := strconv.ParseInt(line[0], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		origin, ok := ids[line[1]]
+
+		if !ok {
+			panic("unknown origin " + line[1] + " for item " + line[0])
+		}
+
+		itemOrigins[item] = origin
+	}
+
+	return &itemOrigins
+}
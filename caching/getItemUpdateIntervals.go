@@ -0,0 +1,82 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+)
+
+// getItemUpdateIntervals reads how often items change from the update_interval column of the load file
+// older load files do not have that column, then no item ever changes
+func getItemUpdateIntervals(loadFile string) *map[int64]int64 {
+	updateIntervals := make(map[int64]int64)
+
+	load, err := os.Open(loadFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer load.Close()
+
+	csvr := csv.NewReader(load)
+
+	header, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	column := -1
+
+	for i, h := range header {
+		if h == "update_interval" {
+			column = i
+		}
+	}
+
+	if column < 0 {
+		return &updateIntervals
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		item, err := strconv.ParseInt(line[0], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		// pandas might write the interval as "300.0"
+		interval, err := strconv.ParseFloat(line[column], 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		updateIntervals[item] = int64(interval)
+	}
+
+	return &updateIntervals
+}
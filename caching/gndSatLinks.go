@@ -59,7 +59,7 @@ func getGroundSatLinks(gslFile string) *map[int64]gndSatLink {
 		}
 
 		// third item: distance
-		distance, err := strconv.ParseInt(line[1], 10, 64)
+		distance, err := strconv.ParseInt(line[2], 10, 64)
 
 		if err != nil {
 			panic(err)
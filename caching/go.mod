@@ -3,8 +3,6 @@ module github.com/pfandzelter/caching
 go 1.15
 
 require (
-	github.com/narqo/psqr v0.0.0-20180429201159-0c504f4fe08c
 	github.com/pelletier/go-toml v1.8.1
-	github.com/pkg/profile v1.5.0
 	github.com/schollz/progressbar/v3 v3.7.1
 )
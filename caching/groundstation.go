@@ -17,33 +17,47 @@
 
 package main
 
-import (
-	"math/rand"
-	"strconv"
-)
+import "strconv"
 
 // offset should be greater than the number of ground stations
 const offset int64 = 1000000
 
 type groundstationCache struct {
 	name             string
-	cache            map[int64]map[int64]map[int64]struct{}
+	cache            *nodeCaches
+	consistency      *cacheConsistency
 	maxClientsPerGST int64
 	gstPopulation    map[int64]int64
 	nodes            []int64
+	// picks one of the caches of a ground station for each request
+	rng *seededRand
+
+	metrics []metric
 }
 
-func newGroundstation(maxClientsPerGST int64, gstPopulation map[int64]int64) *groundstationCache {
+func init() {
+	registerStrategy("GROUND-STATION", func(conf *strategyConfig, env *strategyEnv) strategy {
+		maxClients := conf.mustInt64("max_clients")
+
+		if maxClients <= 0 {
+			panic("GROUND-STATION: max_clients must be positive")
+		}
+
+		cacheConf := getCacheConfig(conf)
+		consistency := getCacheConsistency(conf, env)
+		name := conf.getName("GROUND-STATION" + "-" + strconv.FormatInt(maxClients, 10) + cacheConf.suffix() + consistency.suffix())
+
+		return newGroundstation(name, maxClients, *getGSTPopulation(env.cityFile), cacheConf, consistency, env.itemSizes, conf.seedFor("ground-station"))
+	})
+}
 
-	rand.Seed(0)
+func newGroundstation(name string, maxClientsPerGST int64, gstPopulation map[int64]int64, cacheConf cacheConfig, consistency *cacheConsistency, itemSizes *map[int64]int64, seed int64) *groundstationCache {
 
-	cache := make(map[int64]map[int64]map[int64]struct{})
 	// test gst set
 	nodes := make([]int64, 0)
 
 	for gst, pop := range gstPopulation {
 		numGst := pop/maxClientsPerGST + 1
-		cache[gst] = make(map[int64]map[int64]struct{})
 
 		for i := int64(0); i < numGst; i++ {
 			x := offset*int64(-i) + gst
@@ -53,16 +67,17 @@ func newGroundstation(maxClientsPerGST int64, gstPopulation map[int64]int64) *gr
 			}
 
 			nodes = append(nodes, x)
-			cache[gst][x] = make(map[int64]struct{})
 		}
 	}
 
 	return &groundstationCache{
-		name:             "GROUND-STATION" + "-" + strconv.FormatInt(maxClientsPerGST, 10),
-		cache:            cache,
+		name:             name,
+		cache:            newNodeCaches(cacheConf, itemSizes),
+		consistency:      consistency,
 		gstPopulation:    gstPopulation,
 		maxClientsPerGST: maxClientsPerGST,
 		nodes:            nodes,
+		rng:              newSeededRand(seed),
 	}
 }
 
@@ -70,111 +85,147 @@ func (C *groundstationCache) getStoreNodes() int64 {
 	return int64(len(C.nodes))
 }
 
+// only satellites and links fail, ground stations are always up
+func (C *groundstationCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return 0, 0
+}
+
 func (C *groundstationCache) getRandInGST(gst int64) int64 {
-	i := rand.Intn(int(C.gstPopulation[gst]/C.maxClientsPerGST + 1))
+	i := C.rng.Intn(int(C.gstPopulation[gst]/C.maxClientsPerGST + 1))
 	return offset*int64(-i) + gst
 
 }
 
+// gstLocation of a cache is the ground station it belongs to
+func gstLocation(cacheGst int64) int64 {
+	return cacheGst % offset
+}
+
+func (C *groundstationCache) getMetrics() *[]metric {
+	// without admission control, there is nothing to report
+	if C.metrics == nil {
+		return nil
+	}
+
+	return &C.metrics
+}
+
 func (C *groundstationCache) getName() string {
 	return C.name
 }
 
-func (C *groundstationCache) getStore(scache map[int64]map[int64]map[int64]struct{}) *[]storeRecord {
+// storeIsIncremental is true as long as nothing ever leaves our caches, i.e., they do not evict and do not invalidate anything
+// we then only need to return the items that were added in a step
+func (C *groundstationCache) storeIsIncremental() bool {
+	return !C.cache.bounded() && !C.consistency.removesItems()
+}
 
-	storeRecords := []storeRecord{}
+func (C *groundstationCache) getStore(added *[]storeRecord) *[]storeRecord {
 
-	for gst := range scache {
-		for node := range scache[gst] {
-			for item := range scache[gst][node] {
-				storeRecords = append(storeRecords, storeRecord{
-					node: node,
-					item: item,
-				})
-			}
-		}
+	if !C.storeIsIncremental() {
+		return C.cache.getStore()
 	}
 
-	return &storeRecords
+	return added
 }
 
-func (C *groundstationCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord) {
+func (C *groundstationCache) save(w *checkpointWriter) {
+	C.rng.save(w)
+	C.cache.save(w)
+}
 
-	txRecords := []txRecord{}
-	// we always need as many cache records as we have requests
-	cacheRecords := make([]cacheRecord, 0, len(*requests))
-	// same goes for hops records
-	hopsRecords := make([]hopsRecord, 0, len(*requests))
+func (C *groundstationCache) load(r *checkpointReader) {
+	C.rng.load(r)
+	C.cache.load(r)
+}
 
-	// prepare a new cache that will store additions to the cache
-	scache := make(map[int64]map[int64]map[int64]struct{})
+func (C *groundstationCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, gstLocation, shortestSatPaths, gndSatLinks, &records.tx)
 
-	// for gst := range C.cache {
-	// 	scache[gst] = copyCache(C.cache[gst])
-	// }
+	// prepare a new cache that will store additions to the cache
+	scache := make(map[int64]map[int64]struct{})
+	// also keep the order of additions, this matters for eviction
+	added := []storeRecord{}
+	// outdated copies that are replaced, they are not new additions
+	replaced := []storeRecord{}
 
 	for _, req := range *requests {
-		hops := int64(0)
 		success := false
+		stale := false
 
 		// check if the ground station that makes the request has the item in cache
 		// the ground station is a random one found in gstSet[<this_ground_station>]
 		actualGST := req.path[0]
 		cacheGst := C.getRandInGST(actualGST)
 
-		if _, ok := C.cache[actualGST][cacheGst]; ok {
-			if _, ok := C.cache[actualGST][cacheGst][req.item]; ok {
-				success = true
-			}
-		}
-
-		// if it didn't: request to origin server
-		if !success {
-			for i := 0; i < len(req.path)-1; i++ {
-				source := req.path[i]
-				target := req.path[i+1]
+		if version, ok := C.cache.version(cacheGst, req.item); ok && C.consistency.usable(version, req) {
+			success = true
+			stale = version != req.version
 
-				txRecords = append(txRecords, txRecord{
-					source:    source,
-					target:    target,
-					bandwidth: req.bandwidth,
-				})
+			C.cache.hit(cacheGst, req.item)
+			C.consistency.validate(actualGST, req, shortestSatPaths, gndSatLinks, &records.tx)
+		}
 
-				hops++
-			}
+		if success {
+			// the ground station cache is local to the client, so the request does not travel at all
+			records.record(req, true, stale, 0, 0)
+		} else {
+			// if it didn't: request to origin server
+			records.served(req, len(req.path)-1, false)
 		}
 
-		cacheRecords = append(cacheRecords, cacheRecord{
-			item:    req.item,
-			success: success,
-		})
+		// write that item into the cache for the next round
+		// items that are in the cache already are not new additions
+		if success {
+			continue
+		}
 
-		hopsRecords = append(hopsRecords, hopsRecord{
-			item: req.item,
-			hops: hops,
-		})
+		if _, ok := scache[cacheGst]; !ok {
+			scache[cacheGst] = make(map[int64]struct{})
+		}
 
-		// write that item into the cache for the next round
-		if _, ok := scache[actualGST]; !ok {
-			scache[actualGST] = make(map[int64]map[int64]struct{})
+		if _, ok := scache[cacheGst][req.item]; ok {
+			continue
 		}
 
-		if _, ok := scache[actualGST][cacheGst]; !ok {
-			scache[actualGST][cacheGst] = make(map[int64]struct{})
+		scache[cacheGst][req.item] = struct{}{}
+
+		if C.cache.has(cacheGst, req.item) {
+			replaced = append(replaced, storeRecord{
+				node: cacheGst,
+				item: req.item,
+			})
+			continue
 		}
 
-		scache[actualGST][cacheGst][req.item] = struct{}{}
+		added = append(added, storeRecord{
+			node: cacheGst,
+			item: req.item,
+		})
 	}
 
 	// transfer the items from the temporary scache into the main cache for next round
-	for actualGST := range scache {
-		for cacheGst := range scache[actualGST] {
-			for item := range scache[actualGST][cacheGst] {
-				C.cache[actualGST][cacheGst][item] = struct{}{}
-			}
+	for _, r := range replaced {
+		C.cache.add(r.node, r.item, C.consistency.versions.version(r.item, time))
+	}
+
+	// items that were not admitted are not stored
+	stored := make([]storeRecord, 0, len(added))
+
+	for _, r := range added {
+		C.cache.add(r.node, r.item, C.consistency.versions.version(r.item, time))
+
+		if C.cache.has(r.node, r.item) {
+			stored = append(stored, r)
 		}
 	}
 
-	return &txRecords, C.getStore(scache), &cacheRecords, &hopsRecords
+	C.metrics = C.cache.admissionMetrics()
+
+	return records.result(C.getStore(&stored))
 
 }
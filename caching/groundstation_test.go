@@ -0,0 +1,88 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "testing"
+
+// the store the writer reports has to match what the caches hold, also after items were invalidated
+func TestGroundstationStoreAfterInvalidation(t *testing.T) {
+	sizes := map[int64]int64{1: 100, 2: 50}
+	// item 1 changes every 10 seconds, item 2 never does
+	intervals := map[int64]int64{1: 10}
+
+	consistency := &cacheConsistency{
+		mode:     consistencyInvalidate,
+		versions: newItemVersions(&intervals),
+		origins:  map[int64]int64{1: -2, 2: -2},
+	}
+
+	C := newGroundstation("GROUND-STATION", 10, map[int64]int64{-1: 1}, cacheConfig{policy: "LRU"}, consistency, &sizes, 0)
+
+	if C.storeIsIncremental() {
+		t.Fatal("store is incremental although items are invalidated")
+	}
+
+	f := &aw{
+		itemSizes:          &sizes,
+		cachedStoreRecords: make(map[string]map[int64]int64),
+		incrementalStore:   map[string]bool{C.getName(): C.storeIsIncremental()},
+	}
+
+	shortestSatPaths := map[int64]map[int64]satPath{}
+	gndSatLinks := map[int64]gndSatLink{-1: {sat: 0}, -2: {sat: 0}}
+
+	steps := []struct {
+		time  int64
+		items []int64
+	}{
+		{time: 0, items: []int64{1, 2}},
+		// item 1 has changed and is invalidated
+		{time: 9, items: []int64{2}},
+		// and fetched again
+		{time: 10, items: []int64{1}},
+	}
+
+	for _, s := range steps {
+		requests := make([]*request, 0, len(s.items))
+
+		for _, item := range s.items {
+			requests = append(requests, &request{
+				item:      item,
+				bandwidth: sizes[item],
+				path:      []int64{-1, 0, -2},
+				version:   consistency.versions.version(item, s.time),
+			})
+		}
+
+		_, store, _, _, _ := C.stepTo(s.time, &shortestSatPaths, &gndSatLinks, &requests)
+
+		reported := f.storePerNode(C.getName(), store)
+
+		for node, n := range C.cache.nodes {
+			var cached int64
+
+			for item := range n.items {
+				cached += sizes[item]
+			}
+
+			if reported[node] != cached {
+				t.Errorf("time %d: node %d stores %d bytes, reported %d", s.time, node, cached, reported[node])
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// writeItems writes the requests and cache hits of each requested item, one item per line
+// the analysis tool joins these with the popularity and size of each item from the load file
+func writeItems(filename string, records *[]cacheRecord) {
+	requests := make(map[int64]int64)
+	hits := make(map[int64]int64)
+
+	for _, r := range *records {
+		requests[r.item]++
+
+		if r.success {
+			hits[r.item]++
+		}
+	}
+
+	items := make([]int64, 0, len(requests))
+
+	for item := range requests {
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+
+	itemsFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer itemsFile.Close()
+
+	buf := bufio.NewWriter(itemsFile)
+
+	buf.WriteString("item,requests,hits\n")
+
+	for _, item := range items {
+		buf.WriteString(strconv.FormatInt(item, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(requests[item], 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(hits[item], 10))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
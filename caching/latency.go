@@ -0,0 +1,105 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// speed of light in meters per second, for both laser ISLs and radio links to the ground
+const speedOfLight = 299792458.0
+
+// latencyModel turns the distance and hops of a request into the time until the client has the item
+type latencyModel struct {
+	// processing and queueing delay in milliseconds, per hop and direction
+	perHop float64
+}
+
+// getLatencyModel reads the [latency] table of the workload config
+// without it, latency is only the propagation delay
+func getLatencyModel(workloadConfig *toml.Tree) *latencyModel {
+	c := getConfigTable(workloadConfig, "latency")
+
+	processing := c.getFloat64("processing_delay", 0)
+	queueing := c.getFloat64("queueing_delay", 0)
+
+	if processing < 0 || queueing < 0 {
+		panic("latency: processing_delay and queueing_delay must not be negative")
+	}
+
+	return &latencyModel{
+		perHop: processing + queueing,
+	}
+}
+
+// latency of a request in milliseconds, for the request to reach the node that serves it and the item to come back
+func (M *latencyModel) latency(r latencyRecord) float64 {
+	return 2*float64(r.distance)/speedOfLight*1000 + 2*float64(r.hops)*M.perHop
+}
+
+// groundDistance is the length of the link between a ground node and its satellite
+func groundDistance(gndSatLinks *map[int64]gndSatLink, gnd int64) int64 {
+	l, ok := (*gndSatLinks)[gnd]
+
+	if !ok {
+		panic("no satellite link for ground node " + strconv.FormatInt(gnd, 10))
+	}
+
+	return l.distance
+}
+
+// requestDistance is the length of the path of a request from the client to the node at index servedBy
+// we only know the distances of shortest paths between satellites that serve ground stations
+// if a request is served by a satellite in between, the distance to it is estimated from its share of the ISL hops
+func requestDistance(req *request, servedBy int, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink) int64 {
+	if servedBy == 0 {
+		return 0
+	}
+
+	origin := len(req.path) - 1
+	firstSat := req.path[1]
+	lastSat := req.path[origin-1]
+
+	// uplink of the client
+	distance := groundDistance(gndSatLinks, req.path[0])
+
+	// ISLs up to the serving satellite or the one next to the origin
+	reached := servedBy
+	if reached == origin {
+		reached = origin - 1
+	}
+
+	if reached > 1 {
+		if p, ok := getSatPath(shortestSatPaths, firstSat, req.path[reached]); ok {
+			distance += p.distance
+		} else if p, ok := getSatPath(shortestSatPaths, firstSat, lastSat); ok {
+			distance += p.distance * int64(reached-1) / int64(origin-2)
+		} else {
+			panic("no path between satellites " + strconv.FormatInt(firstSat, 10) + " and " + strconv.FormatInt(lastSat, 10))
+		}
+	}
+
+	// downlink to the origin
+	if servedBy == origin {
+		distance += groundDistance(gndSatLinks, req.path[origin])
+	}
+
+	return distance
+}
@@ -0,0 +1,203 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// types of links, each with its own capacity
+const (
+	linkUplink = iota
+	linkDownlink
+	linkIntraPlane
+	linkCrossPlane
+)
+
+var linkTypes = []string{"uplink", "downlink", "intra_plane", "cross_plane"}
+
+// linkCapacities are the bytes a link of each type can carry in one step
+// 0 means the link is not limited
+type linkCapacities struct {
+	perStep       []float64
+	constellation *constellation
+}
+
+// getLinkCapacities reads the [links] table of the workload config, it returns nil if there is none
+// capacities are given in bytes per second
+func getLinkCapacities(workloadConfig *toml.Tree, C *constellation, stepLength int64) *linkCapacities {
+	if !workloadConfig.Has("links") {
+		return nil
+	}
+
+	c := getConfigTable(workloadConfig, "links")
+
+	L := &linkCapacities{
+		perStep:       make([]float64, len(linkTypes)),
+		constellation: C,
+	}
+
+	for t, name := range linkTypes {
+		capacity := c.getFloat64(name, 0)
+
+		if capacity < 0 {
+			panic("links: " + name + " must not be negative")
+		}
+
+		L.perStep[t] = capacity * float64(stepLength)
+	}
+
+	return L
+}
+
+// linkType tells what kind of link goes from source to target
+// links between two ground nodes are not part of the network, they do not have a type
+func linkType(C *constellation, source int64, target int64) (int, bool) {
+	switch {
+	case source < 0 && target < 0:
+		return 0, false
+	case source < 0:
+		return linkUplink, true
+	case target < 0:
+		return linkDownlink, true
+	case C.planeOf(source) == C.planeOf(target):
+		return linkIntraPlane, true
+	default:
+		return linkCrossPlane, true
+	}
+}
+
+// direction returns the link a record puts its bytes on
+// traffic for a request is recorded along the path of the request, but the item flows the other way
+func direction(r txRecord) [2]int64 {
+	if r.req != nil {
+		return [2]int64{r.target, r.source}
+	}
+
+	return [2]int64{r.source, r.target}
+}
+
+// writeLinks sums up the traffic on each directed link in a step and compares it to the capacity of the link
+// it writes the following to file:
+// * avg, median, 95th pcntl, 99th pcntl, and max utilization of all limited links that carried traffic
+// * number of overloaded links, i.e., links that were asked to carry more than their capacity, in total and per type
+// * share of requests whose item crossed an overloaded link
+func (L *linkCapacities) writeLinks(filename string, records *[]txRecord, numRequests int) {
+	load := make(map[[2]int64]int64)
+
+	for _, r := range *records {
+		load[direction(r)] += r.bandwidth
+	}
+
+	utilization := make([]float64, 0, len(load))
+	overloaded := make(map[[2]int64]struct{})
+	overloadedPerType := make([]int64, len(linkTypes))
+
+	for l, bytes := range load {
+		t, ok := linkType(L.constellation, l[0], l[1])
+
+		if !ok || L.perStep[t] == 0 {
+			continue
+		}
+
+		u := float64(bytes) / L.perStep[t]
+		utilization = append(utilization, u)
+
+		if u > 1 {
+			overloaded[l] = struct{}{}
+			overloadedPerType[t]++
+		}
+	}
+
+	saturated := make(map[*request]struct{})
+
+	for _, r := range *records {
+		if r.req == nil {
+			continue
+		}
+
+		if _, ok := overloaded[direction(r)]; ok {
+			saturated[r.req] = struct{}{}
+		}
+	}
+
+	linksFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer linksFile.Close()
+
+	buf := bufio.NewWriter(linksFile)
+
+	// without any limited link that carried traffic, there is no utilization to report
+	stats := []string{"avg", "median", "95th", "99th", "max"}
+	values := make([]float64, len(stats))
+
+	if len(utilization) > 0 {
+		sort.Float64s(utilization)
+
+		var total float64
+
+		for _, u := range utilization {
+			total += u
+		}
+
+		values = []float64{
+			total / float64(len(utilization)),
+			percentile(utilization, 50),
+			percentile(utilization, 95),
+			percentile(utilization, 99),
+			utilization[len(utilization)-1],
+		}
+	}
+
+	for i, stat := range stats {
+		buf.WriteString("utilization_")
+		buf.WriteString(stat)
+		buf.WriteString(",")
+		if len(utilization) > 0 {
+			buf.WriteString(strconv.FormatFloat(values[i], 'f', -1, 64))
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("overloaded,")
+	buf.WriteString(strconv.Itoa(len(overloaded)))
+	buf.WriteString("\n")
+
+	for t, name := range linkTypes {
+		buf.WriteString("overloaded_")
+		buf.WriteString(name)
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(overloadedPerType[t], 10))
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("saturated_requests,")
+	buf.WriteString(strconv.FormatFloat(float64(len(saturated))/float64(numRequests), 'f', -1, 64))
+	buf.WriteString("\n")
+
+	buf.Flush()
+}
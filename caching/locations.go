@@ -0,0 +1,172 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// locationTable knows the name and population of every ground station
+type locationTable struct {
+	names      map[int64]string
+	population map[int64]int64
+}
+
+// getLocationTable reads the cities file, ground station ids are assigned like in getGSTPopulation
+func getLocationTable(cityFile string) *locationTable {
+	L := &locationTable{
+		names:      make(map[int64]string),
+		population: *getGSTPopulation(cityFile),
+	}
+
+	cities, err := os.Open(cityFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer cities.Close()
+
+	csvr := csv.NewReader(cities)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	var id int64 = -1
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		// same as for the population, lines without one are skipped
+		if _, err := strconv.ParseInt(line[1], 10, 64); err != nil {
+			continue
+		}
+
+		L.names[id] = line[0]
+
+		id--
+	}
+
+	return L
+}
+
+// locationStats are the sums over all requests of one ground station in one step
+type locationStats struct {
+	requests int64
+	hits     int64
+	bytes    int64
+	hitBytes int64
+	hops     int64
+	latency  float64
+}
+
+// writeLocations writes one line per ground station that made requests in a step:
+// * name and population of the location
+// * number of requests and bytes requested
+// * hit ratio and byte hit ratio
+// * avg hops and avg latency in milliseconds
+func (L *locationTable) writeLocations(filename string, cacheRecords *[]cacheRecord, hopsRecords *[]hopsRecord, latencyRecords *[]latencyRecord, latency *latencyModel) {
+	stats := make(map[int64]*locationStats)
+
+	get := func(gst int64) *locationStats {
+		s, ok := stats[gst]
+
+		if !ok {
+			s = &locationStats{}
+			stats[gst] = s
+		}
+
+		return s
+	}
+
+	for _, r := range *cacheRecords {
+		s := get(r.gst)
+
+		s.requests++
+		s.bytes += r.bytes
+
+		if r.success {
+			s.hits++
+			s.hitBytes += r.bytes
+		}
+	}
+
+	for _, r := range *hopsRecords {
+		get(r.gst).hops += r.hops
+	}
+
+	for _, r := range *latencyRecords {
+		get(r.gst).latency += latency.latency(r)
+	}
+
+	gsts := make([]int64, 0, len(stats))
+
+	for gst := range stats {
+		gsts = append(gsts, gst)
+	}
+
+	// -1 first, like in the cities file
+	sort.Slice(gsts, func(i, j int) bool { return gsts[i] > gsts[j] })
+
+	locationsFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer locationsFile.Close()
+
+	buf := bufio.NewWriter(locationsFile)
+
+	buf.WriteString("gst,name,population,requests,bytes,hit_ratio,byte_hit_ratio,avg_hops,avg_latency\n")
+
+	for _, gst := range gsts {
+		s := stats[gst]
+
+		byteHitRatio := 0.0
+		if s.bytes > 0 {
+			byteHitRatio = float64(s.hitBytes) / float64(s.bytes)
+		}
+
+		buf.WriteString(strconv.FormatInt(gst, 10))
+		buf.WriteString(",")
+		buf.WriteString(L.names[gst])
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(L.population[gst], 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(s.requests, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(s.bytes, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(float64(s.hits)/float64(s.requests), 'f', -1, 64))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(byteHitRatio, 'f', -1, 64))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(float64(s.hops)/float64(s.requests), 'f', -1, 64))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatFloat(s.latency/float64(s.requests), 'f', -1, 64))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
@@ -0,0 +1,161 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "math"
+
+const (
+	// only the first satellite on the path of a request caches items
+	lookupFirst = "FIRST"
+	// all satellites on the path of a request cache items
+	lookupOnPath = "ON-PATH"
+
+	// leave copy everywhere: every satellite between the client and the node that served the request gets a copy
+	insertionLCE = "LCE"
+	// leave copy down: only the satellite one hop closer to the client than the node that served the request gets a copy
+	insertionLCD = "LCD"
+	// satellites get a copy with a probability that depends on their position on the path (Psaras et al., ICN '12)
+	insertionProbCache = "PROBCACHE"
+
+	// target time window of ProbCache
+	defaultProbCacheTW = 10.0
+)
+
+// satelliteLookup decides where satellite strategies look for an item and where they leave copies of it
+type satelliteLookup struct {
+	mode      string
+	insertion string
+	tw        float64
+	rng       *seededRand
+}
+
+func getSatelliteLookup(conf *strategyConfig) *satelliteLookup {
+	L := &satelliteLookup{
+		mode:      conf.getString("lookup", lookupFirst),
+		insertion: conf.getString("insertion", insertionLCE),
+		tw:        conf.getFloat64("probcache_tw", defaultProbCacheTW),
+		rng:       newSeededRand(conf.seedFor("insertion")),
+	}
+
+	switch L.mode {
+	case lookupFirst, lookupOnPath:
+	default:
+		panic(conf.name + ": unknown lookup " + L.mode + ", use " + lookupFirst + " or " + lookupOnPath)
+	}
+
+	switch L.insertion {
+	case insertionLCE, insertionLCD, insertionProbCache:
+	default:
+		panic(conf.name + ": unknown insertion " + L.insertion + ", use " + insertionLCE + ", " + insertionLCD + ", or " + insertionProbCache)
+	}
+
+	if L.tw <= 0 {
+		panic(conf.name + ": probcache_tw must be positive")
+	}
+
+	return L
+}
+
+// suffix is appended to the name of a strategy so that different lookup modes can be told apart
+func (L *satelliteLookup) suffix() string {
+	if L.mode == lookupFirst {
+		return ""
+	}
+
+	return "-" + L.mode + "-" + L.insertion
+}
+
+// find returns the index in the path of a request of the node that serves it
+// that is either a satellite that has a usable copy of the item in cache or the origin at the end of the path
+func (L *satelliteLookup) find(req *request, scache map[int64]map[int64]int64, S *cacheConsistency) int {
+	origin := len(req.path) - 1
+
+	// the satellites are everything between the ground station at the start and the origin at the end
+	last := 1
+	if L.mode == lookupOnPath {
+		last = origin - 1
+	}
+
+	for i := 1; i <= last; i++ {
+		if _, ok := scache[req.path[i]]; ok {
+			if version, ok := scache[req.path[i]][req.item]; ok && S.usable(version, req) {
+				return i
+			}
+		}
+	}
+
+	return origin
+}
+
+// insert leaves copies of the item of a request on the way back from the node that served it
+// it returns the node and item of everything that was evicted to make room
+func (L *satelliteLookup) insert(req *request, servedBy int, cache *nodeCaches) [][2]int64 {
+	var evicted [][2]int64
+
+	add := func(node int64) {
+		for _, item := range cache.add(node, req.item, req.version) {
+			evicted = append(evicted, [2]int64{node, item})
+		}
+	}
+
+	// a satellite that served the request keeps the copy it has, even if it is outdated
+	if L.mode == lookupFirst {
+		if servedBy > 1 {
+			add(req.path[1])
+		}
+
+		return evicted
+	}
+
+	switch L.insertion {
+	case insertionLCE:
+		for i := 1; i < servedBy; i++ {
+			add(req.path[i])
+		}
+	case insertionLCD:
+		if servedBy > 1 {
+			add(req.path[servedBy-1])
+		}
+	case insertionProbCache:
+		// c is the length of the path from the client to the node that served the request
+		// x is the distance of a node from the node that served the request
+		c := float64(servedBy)
+
+		for i := 1; i < servedBy; i++ {
+			x := float64(servedBy - i)
+
+			timesIn := (c - x + 1) / L.tw
+			cacheWeight := x / c
+
+			if L.rng.Float64() < math.Min(1, timesIn*cacheWeight) {
+				add(req.path[i])
+			}
+		}
+	}
+
+	return evicted
+}
+
+// only ProbCache draws random numbers, everything else does not change during a run
+func (L *satelliteLookup) save(w *checkpointWriter) {
+	L.rng.save(w)
+}
+
+func (L *satelliteLookup) load(r *checkpointReader) {
+	L.rng.load(r)
+}
@@ -28,16 +28,23 @@ import (
 
 func main() {
 
-	strategies := []string{"NONE", "GROUND-STATION", "SATELLITE", "SATELLITE-TIMEOUT", "SATELLITE-VIRTUAL"}
-
-	maxClientsPerGST := []int64{10000, 100, 10}
-
-	if len(os.Args) != 2 {
+	if len(os.Args) != 2 && len(os.Args) != 3 {
 		panic("not enough arguments given")
 	}
 
 	conf := os.Args[1]
 
+	// continue from the last checkpoint instead of starting over
+	resume := false
+
+	if len(os.Args) == 3 {
+		if os.Args[2] != "resume" {
+			panic("unknown argument " + os.Args[2] + ", use resume")
+		}
+
+		resume = true
+	}
+
 	config, err := toml.LoadFile(conf)
 
 	if err != nil {
@@ -65,6 +72,12 @@ func main() {
 	stepLength := workloadConfig.Get("step_length").(int64)
 	numRequest := int(workloadConfig.Get("requestamount").(int64))
 
+	warmup, dropWarmup := getWarmup(workloadConfig, stepLength)
+
+	latency := getLatencyModel(workloadConfig)
+
+	checkpointSteps := getCheckpointSteps(workloadConfig)
+
 	err = os.MkdirAll(path.Join(workloadFolder, "cache"), os.ModePerm)
 
 	if err != nil {
@@ -73,55 +86,73 @@ func main() {
 
 	cacheFiles := path.Join(workloadFolder, "cache", "c.csv")
 
+	checkpointFile := path.Join(workloadFolder, "cache", "checkpoint")
+
 	resultFiles := path.Join(workloadFolder, "results", "r.csv")
 
 	loadFile := path.Join(workloadFolder, workloadConfig.Get("loadfile").(string))
 
 	cityFile := path.Join(workloadFolder, workloadConfig.Get("cities").(string))
 
+	locationFile := path.Join(workloadFolder, workloadConfig.Get("locations").(string))
+
 	itemSizes := getItemSizes(loadFile)
 
-	C := make([]strategy, len(strategies)+len(maxClientsPerGST)-1)
-
-	i := 0
-	for _, s := range strategies {
-		switch s {
-		case "NONE":
-			C[i] = newNone()
-		case "GROUND-STATION":
-			gstPopulation := getGSTPopulation(cityFile)
-			for _, m := range maxClientsPerGST {
-				C[i] = newGroundstation(m, *gstPopulation)
-				i++
-			}
-			i = i - 1
-		case "SATELLITE":
-			C[i] = newSatellite()
-		case "SATELLITE-VIRTUAL":
-			C[i] = newSatelliteVirtual(itemSizes)
-		case "SATELLITE-TIMEOUT":
-			C[i] = newSatelliteTimeout(itemSizes)
-		default:
-			panic("Unknown caching strategy: " + s)
-		}
+	itemVersions := newItemVersions(getItemUpdateIntervals(loadFile))
 
-		i++
-	}
+	constellation := getConstellation(getConfigTable(workloadConfig, "constellation"))
+
+	links := getLinkCapacities(workloadConfig, constellation, stepLength)
+
+	energy := getEnergyModel(workloadConfig, constellation, stepLength)
+
+	failures := getFailures(workloadConfig, workloadFolder, constellation, steps, stepLength)
+
+	strategyConfigs := getStrategyConfigs(workloadConfig)
+
+	C := newStrategies(strategyConfigs, &strategyEnv{
+		itemSizes:     itemSizes,
+		itemVersions:  itemVersions,
+		cityFile:      cityFile,
+		loadFile:      loadFile,
+		locationFile:  locationFile,
+		constellation: constellation,
+		resultFiles:   resultFiles,
+		steps:         steps,
+		stepLength:    stepLength,
+		numRequests:   numRequest,
+	})
+
+	writeStrategies(path.Join(workloadFolder, "cache", "strategies.csv"), C, strategyConfigs)
+	writeWarmup(path.Join(workloadFolder, "cache", "warmup.csv"), warmup, dropWarmup)
 
 	fileWriteC := make(chan writeSet)
 
 	storeNodesPerStrategy := make(map[string]int64)
+	incrementalStorePerStrategy := make(map[string]bool)
 
 	for _, c := range C {
 		storeNodesPerStrategy[c.getName()] = c.getStoreNodes()
+
+		if i, ok := c.(incrementalStore); ok {
+			incrementalStorePerStrategy[c.getName()] = i.storeIsIncremental()
+		}
+	}
+
+	var time int64 = 0
+	var writer *writerState
+
+	if resume {
+		time, writer = readCheckpoint(checkpointFile, C)
 	}
 
-	go newAvgWriter(cacheFiles, itemSizes, fileWriteC, storeNodesPerStrategy)
+	start := time
+
+	go newAvgWriter(cacheFiles, itemSizes, fileWriteC, storeNodesPerStrategy, incrementalStorePerStrategy, warmup, dropWarmup, links, latency, energy, getLocationTable(cityFile), writer)
 	// go newFileWriter(cacheFiles, fileWriteC)
 
 	pbar := progressbar.Default(steps)
-
-	var time int64 = 0
+	pbar.Add(int(start / stepLength))
 
 	coord := make(chan struct{}, len(C))
 
@@ -138,31 +169,68 @@ func main() {
 		gndSatLinks := getGroundSatLinks(resultFiles + strconv.FormatInt(time, 10) + "gnd_sat_links")
 
 		// 3. read paths/requests
-		requests := getRequests(resultFiles+strconv.FormatInt(time, 10)+"paths", numRequest)
+		requests := getRequests(resultFiles+strconv.FormatInt(time, 10)+"paths", numRequest, time, itemVersions)
+
+		// 4. find out what is down
+		var down *failureState
+
+		if failures != nil {
+			down = failures.at(time)
+			down.flag(requests)
+		}
 
 		for range C {
 			<-coord
 		}
 
+		// all previous steps are done and their records have been sent to the writer
+		if checkpointSteps > 0 && time != start && (time/stepLength)%checkpointSteps == 0 {
+			state := make(chan *writerState)
+			fileWriteC <- writeSet{state: state}
+			writeCheckpoint(checkpointFile, time, C, <-state)
+		}
+
 		for i, c := range C {
 
-			go func(strategy string, cache *strategy, c *chan struct{}, t int64) {
+			go func(strategy string, cache *strategy, c *chan struct{}, t int64, down *failureState) {
+				var lostItems, lostBytes int64
+				if down != nil {
+					lostItems, lostBytes = (*cache).fail(down.nodes)
+				}
+
 				// 5. pass variables to caching strategy
-				txRecords, storeRecords, cacheRecords, hopsRecords := (*cache).stepTo(t, shortestSatPaths, gndSatLinks, requests)
+				txRecords, storeRecords, cacheRecords, hopsRecords, latencyRecords := (*cache).stepTo(t, shortestSatPaths, gndSatLinks, requests)
+
+				var metrics *[]metric
+				if m, ok := (*cache).(metricsReporter); ok {
+					metrics = m.getMetrics()
+				}
+
+				if down != nil {
+					m := []metric{}
+					if metrics != nil {
+						m = append(m, *metrics...)
+					}
+
+					m = append(m, metric{name: "lost_items", value: float64(lostItems)}, metric{name: "lost_bytes", value: float64(lostBytes)})
+					metrics = &m
+				}
 
 				// 6. write returns
 				fileWriteC <- writeSet{
-					time:         t,
-					strategyName: strategy,
-					txRecords:    txRecords,
-					storeRecords: storeRecords,
-					cacheRecords: cacheRecords,
-					hopsRecords:  hopsRecords,
+					time:           t,
+					strategyName:   strategy,
+					txRecords:      txRecords,
+					storeRecords:   storeRecords,
+					cacheRecords:   cacheRecords,
+					hopsRecords:    hopsRecords,
+					latencyRecords: latencyRecords,
+					metrics:        metrics,
 				}
 
 				*c <- struct{}{}
 
-			}(c.getName(), &C[i], &coord, time)
+			}(c.getName(), &C[i], &coord, time, down)
 		}
 
 		pbar.Add(1)
@@ -173,5 +241,8 @@ func main() {
 		<-coord
 	}
 
-	fileWriteC <- writeSet{time: -1}
+	// wait for the writer to write its summary
+	done := make(chan struct{})
+	fileWriteC <- writeSet{time: -1, done: done}
+	<-done
 }
@@ -0,0 +1,484 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// cacheConfig describes the node caches of a strategy
+// it is read from the capacity and eviction parameters of a [[strategy]] entry
+type cacheConfig struct {
+	// capacity in bytes per node, 0 means unlimited
+	capacity  int64
+	policy    string
+	admission admissionConfig
+	// seed of the random number generator eviction policies may use
+	seed int64
+}
+
+func getCacheConfig(conf *strategyConfig) cacheConfig {
+	c := cacheConfig{
+		capacity: conf.getInt64("capacity", 0),
+		policy:   conf.getString("eviction", "LRU"),
+		seed:     conf.seedFor("eviction"),
+	}
+
+	c.admission = getAdmissionConfig(conf, c.capacity)
+
+	if c.capacity < 0 {
+		panic(conf.name + ": capacity must not be negative")
+	}
+
+	// fail early rather than in the middle of a run
+	getPolicyFactory(c.policy)
+
+	return c
+}
+
+// suffix is appended to the name of a strategy so that different cache configurations can be told apart
+func (c cacheConfig) suffix() string {
+	if c.capacity == 0 {
+		return c.admission.suffix()
+	}
+
+	return "-" + c.policy + "-" + strconv.FormatInt(c.capacity, 10) + c.admission.suffix()
+}
+
+// nodeCache is the cache of a single store node
+type nodeCache struct {
+	// version of each cached item
+	items  map[int64]int64
+	used   int64
+	policy evictionPolicy
+}
+
+// nodeCaches holds the caches of all store nodes of a strategy
+// without a capacity, caches never evict anything, which is what we assumed in the paper
+type nodeCaches struct {
+	capacity  int64
+	policy    policyFactory
+	itemSizes map[int64]int64
+	rng       *seededRand
+	nodes     map[int64]*nodeCache
+	// nil if every item is admitted
+	admission admissionPolicy
+	// items that were not admitted since the last call to admissionMetrics
+	rejected int64
+	// nodes that are down and cannot cache anything
+	down map[int64]struct{}
+}
+
+func newNodeCaches(conf cacheConfig, itemSizes *map[int64]int64) *nodeCaches {
+	N := newNodeCachesWithPolicy(conf.capacity, getPolicyFactory(conf.policy), itemSizes)
+	N.rng = newSeededRand(conf.seed)
+	N.admission = newAdmissionPolicy(conf.admission)
+	return N
+}
+
+// newNodeCachesWithPolicy is for strategies that bring their own eviction policy instead of a registered one
+// those caches admit every item and their policies do not draw random numbers
+func newNodeCachesWithPolicy(capacity int64, policy policyFactory, itemSizes *map[int64]int64) *nodeCaches {
+	return &nodeCaches{
+		capacity:  capacity,
+		policy:    policy,
+		itemSizes: *itemSizes,
+		rng:       newSeededRand(0),
+		nodes:     make(map[int64]*nodeCache),
+	}
+}
+
+func (N *nodeCaches) bounded() bool {
+	return N.capacity > 0
+}
+
+func (N *nodeCaches) getNode(node int64) *nodeCache {
+	n, ok := N.nodes[node]
+
+	if !ok {
+		n = &nodeCache{
+			items: make(map[int64]int64),
+		}
+
+		// no need to keep track of anything if we never evict
+		if N.bounded() {
+			n.policy = N.policy(N.capacity, N.rng.Rand)
+		}
+
+		N.nodes[node] = n
+	}
+
+	return n
+}
+
+func (N *nodeCaches) has(node int64, item int64) bool {
+	n, ok := N.nodes[node]
+
+	if !ok {
+		return false
+	}
+
+	_, ok = n.items[item]
+
+	return ok
+}
+
+// hit tells the eviction policy of a node that an item was requested from it
+func (N *nodeCaches) hit(node int64, item int64) {
+	if !N.bounded() || !N.has(node, item) {
+		return
+	}
+
+	N.nodes[node].policy.hit(item)
+}
+
+// version returns the version of the copy of an item a node has
+func (N *nodeCaches) version(node int64, item int64) (int64, bool) {
+	n, ok := N.nodes[node]
+
+	if !ok {
+		return 0, false
+	}
+
+	v, ok := n.items[item]
+
+	return v, ok
+}
+
+// isDown tells whether a node is down and cannot cache anything
+func (N *nodeCaches) isDown(node int64) bool {
+	_, ok := N.down[node]
+	return ok
+}
+
+// add puts a version of an item into the cache of a node, evicting other items if necessary
+// if the node already has the item, only its version is updated
+// items that are not admitted or larger than the capacity are not cached at all
+// it returns the items that had to be evicted
+func (N *nodeCaches) add(node int64, item int64, version int64) []int64 {
+	if _, ok := N.down[node]; ok {
+		return nil
+	}
+
+	n := N.getNode(node)
+
+	if _, ok := n.items[item]; ok {
+		n.items[item] = version
+		return nil
+	}
+
+	size := N.itemSizes[item]
+
+	if N.admission != nil && !N.admission.admit(node, item, size) {
+		N.rejected++
+		return nil
+	}
+
+	return N.put(n, item, version)
+}
+
+// put adds an item to a cache without asking the admission policy
+func (N *nodeCaches) put(n *nodeCache, item int64, version int64) []int64 {
+	size := N.itemSizes[item]
+
+	if !N.bounded() {
+		n.items[item] = version
+		n.used += size
+		return nil
+	}
+
+	if size > N.capacity {
+		return nil
+	}
+
+	n.items[item] = version
+	n.used += size
+	n.policy.add(item, size)
+
+	// policies may decide to evict the new item right away
+	var evicted []int64
+
+	for n.used > N.capacity {
+		e := n.policy.evict()
+
+		if _, ok := n.items[e]; !ok {
+			panic("eviction policy evicted an item that is not in the cache")
+		}
+
+		delete(n.items, e)
+		n.used -= N.itemSizes[e]
+		evicted = append(evicted, e)
+	}
+
+	return evicted
+}
+
+func (N *nodeCaches) remove(node int64, item int64) {
+	if !N.has(node, item) {
+		return
+	}
+
+	n := N.nodes[node]
+
+	delete(n.items, item)
+	n.used -= N.itemSizes[item]
+
+	if N.bounded() {
+		n.policy.remove(item)
+	}
+}
+
+// clear empties all caches
+func (N *nodeCaches) clear() {
+	N.nodes = make(map[int64]*nodeCache)
+}
+
+// fail empties the caches of the nodes that are down and keeps them empty until the next call
+// it returns how many items and bytes were lost
+func (N *nodeCaches) fail(down map[int64]struct{}) (int64, int64) {
+	N.down = down
+
+	var items int64
+	var bytes int64
+
+	for node := range down {
+		n, ok := N.nodes[node]
+
+		if !ok {
+			continue
+		}
+
+		items += int64(len(n.items))
+		bytes += n.used
+
+		delete(N.nodes, node)
+	}
+
+	return items, bytes
+}
+
+// move gives each node the cache of another node, including the state of its eviction policy
+// nodes that do not receive a cache are empty afterwards, as are nodes that are down
+// target must not move two caches to the same node
+func (N *nodeCaches) move(target func(node int64) int64) {
+	nodes := make(map[int64]*nodeCache)
+
+	for node, n := range N.nodes {
+		t := target(node)
+
+		if _, ok := nodes[t]; ok {
+			panic("two caches moved to node " + strconv.FormatInt(t, 10))
+		}
+
+		// a node that is down cannot take anything, the cache is lost
+		if _, ok := N.down[t]; ok {
+			continue
+		}
+
+		nodes[t] = n
+	}
+
+	N.nodes = nodes
+}
+
+// merge moves the caches of the nodes in moves to their targets
+// a target that does not keep a cache of its own gets the cache of the first node moving to it, including the state of its eviction policy
+// the items of any other node are added one by one, bypassing admission as they were admitted before, and items a target has already are skipped
+// it returns the items each node sent to its target
+func (N *nodeCaches) merge(moves map[int64]int64) map[int64][]int64 {
+	sources := make([]int64, 0, len(moves))
+
+	for node := range moves {
+		sources = append(sources, node)
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+
+	detached := make(map[int64]*nodeCache, len(sources))
+
+	for _, node := range sources {
+		if n, ok := N.nodes[node]; ok {
+			detached[node] = n
+			delete(N.nodes, node)
+		}
+	}
+
+	sent := make(map[int64][]int64, len(detached))
+
+	for _, node := range sources {
+		n, ok := detached[node]
+
+		if !ok {
+			continue
+		}
+
+		items := make([]int64, 0, len(n.items))
+
+		for item := range n.items {
+			items = append(items, item)
+		}
+
+		sort.Slice(items, func(i, j int) bool { return items[i] < items[j] })
+
+		t := moves[node]
+
+		// a node that is down cannot take anything, the cache is lost
+		if _, ok := N.down[t]; ok {
+			continue
+		}
+
+		if _, ok := N.nodes[t]; !ok {
+			N.nodes[t] = n
+			sent[node] = items
+			continue
+		}
+
+		target := N.nodes[t]
+
+		for _, item := range items {
+			if _, ok := target.items[item]; ok {
+				continue
+			}
+
+			N.put(target, item, n.items[item])
+			sent[node] = append(sent[node], item)
+		}
+	}
+
+	return sent
+}
+
+// snapshot copies the items and their versions in all caches
+// strategies look up items in a snapshot so that items added in a step are only available in the next one
+func (N *nodeCaches) snapshot() map[int64]map[int64]int64 {
+	cp := make(map[int64]map[int64]int64, len(N.nodes))
+
+	for node, n := range N.nodes {
+		cp[node] = make(map[int64]int64, len(n.items))
+
+		for item, version := range n.items {
+			cp[node][item] = version
+		}
+	}
+
+	return cp
+}
+
+func (N *nodeCaches) getStore() *[]storeRecord {
+	storeRecords := make([]storeRecord, 0, len(N.nodes))
+
+	for node, n := range N.nodes {
+		for item := range n.items {
+			storeRecords = append(storeRecords, storeRecord{
+				node: node,
+				item: item,
+			})
+		}
+	}
+
+	return &storeRecords
+}
+
+// admissionMetrics returns the number of items that were not admitted since the last call
+// it returns nil if every item is admitted
+func (N *nodeCaches) admissionMetrics() []metric {
+	if N.admission == nil {
+		return nil
+	}
+
+	m := []metric{
+		{name: "rejected_admissions", value: float64(N.rejected)},
+	}
+
+	N.rejected = 0
+
+	return m
+}
+
+// nodeCacheState is how the cache of a node is stored in a checkpoint
+type nodeCacheState struct {
+	Node  int64
+	Items map[int64]int64
+	Used  int64
+}
+
+// save writes all caches, the state of their eviction policies, and the state of the admission policy
+func (N *nodeCaches) save(w *checkpointWriter) {
+	N.rng.save(w)
+	w.write(N.rejected)
+
+	nodes := make([]int64, 0, len(N.nodes))
+
+	for node := range N.nodes {
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i] < nodes[j]
+	})
+
+	w.write(len(nodes))
+
+	for _, node := range nodes {
+		n := N.nodes[node]
+
+		w.write(nodeCacheState{
+			Node:  node,
+			Items: n.items,
+			Used:  n.used,
+		})
+
+		if N.bounded() {
+			n.policy.save(w)
+		}
+	}
+
+	if N.admission != nil {
+		N.admission.save(w)
+	}
+}
+
+// load restores what save wrote into empty caches with the same configuration
+func (N *nodeCaches) load(r *checkpointReader) {
+	N.rng.load(r)
+	r.read(&N.rejected)
+
+	var nodes int
+	r.read(&nodes)
+
+	for i := 0; i < nodes; i++ {
+		var s nodeCacheState
+		r.read(&s)
+
+		n := N.getNode(s.Node)
+		n.used = s.Used
+
+		for item, version := range s.Items {
+			n.items[item] = version
+		}
+
+		if N.bounded() {
+			n.policy.load(r)
+		}
+	}
+
+	if N.admission != nil {
+		N.admission.load(r)
+	}
+}
@@ -17,52 +17,49 @@
 
 package main
 
-type noneCache struct{}
+type noneCache struct {
+	name string
+}
+
+func init() {
+	registerStrategy("NONE", func(conf *strategyConfig, env *strategyEnv) strategy {
+		return newNone(conf.getName("NONE"))
+	})
+}
 
-func newNone() *noneCache {
-	return &noneCache{}
+func newNone(name string) *noneCache {
+	return &noneCache{
+		name: name,
+	}
 }
 
 func (C *noneCache) getName() string {
-	return "NONE"
+	return C.name
 }
 
 func (C *noneCache) getStoreNodes() int64 {
 	return 0
 }
 
-func (C *noneCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord) {
+// there is nothing to lose
+func (C *noneCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return 0, 0
+}
 
-	txRecords := []txRecord{}
-	storeRecords := []storeRecord{}
-	// we always need as many cache records as we have requests
-	cacheRecords := make([]cacheRecord, 0, len(*requests))
-	// same goes for hops records
-	hopsRecords := make([]hopsRecord, 0, len(*requests))
+// there is nothing to remember without caches
+func (C *noneCache) save(w *checkpointWriter) {}
 
-	for _, req := range *requests {
-		for i := 0; i < len(req.path)-1; i++ {
-			source := req.path[i]
-			target := req.path[i+1]
+func (C *noneCache) load(r *checkpointReader) {}
 
-			txRecords = append(txRecords, txRecord{
-				source:    source,
-				target:    target,
-				bandwidth: req.bandwidth,
-			})
-		}
+func (C *noneCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
 
-		cacheRecords = append(cacheRecords, cacheRecord{
-			item:    req.item,
-			success: false,
-		})
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
 
-		hopsRecords = append(hopsRecords, hopsRecord{
-			item: req.item,
-			hops: int64(len(req.path)) - 1,
-		})
+	// every request goes all the way to the origin
+	for _, req := range *requests {
+		records.served(req, len(req.path)-1, false)
 	}
 
-	return &txRecords, &storeRecords, &cacheRecords, &hopsRecords
+	return records.result(&[]storeRecord{})
 
 }
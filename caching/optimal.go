@@ -0,0 +1,447 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+)
+
+// optimalCache caches items in the first satellite of a request, just like satelliteCache
+// it knows all requests of the run in advance and evicts the item that is needed again furthest in the future (Belady's MIN)
+// it is not a strategy anyone could implement, but an upper bound for strategies that cache in the first satellite
+// the size-aware variant weighs that time with the size of an item, as evicting a large item frees more space
+type optimalCache struct {
+	name          string
+	constellation *constellation
+	cache         *nodeCaches
+	sizeAware     bool
+	// the next uses of the requests of each step
+	plan *optimalReader
+	// index of the request that is handled right now
+	current int64
+	// index of the next request for the same item at the same satellite, noNextUse if there is none
+	// requests in the same step do not count, as they cannot hit an item that was added in that step
+	next int64
+}
+
+// noNextUse is the next use of an item that is not requested again
+const noNextUse = math.MaxInt64
+
+type beladyPolicy struct {
+	oracle  *optimalCache
+	entries beladyHeap
+	elems   map[int64]*beladyEntry
+}
+
+type beladyEntry struct {
+	item    int64
+	size    int64
+	nextUse int64
+	index   int
+}
+
+type beladyHeap []*beladyEntry
+
+func init() {
+	registerStrategy("OPTIMAL", func(conf *strategyConfig, env *strategyEnv) strategy {
+		capacity := conf.mustInt64("capacity")
+		return newOptimal(conf.getName("OPTIMAL-"+strconv.FormatInt(capacity, 10)), env, capacity, false)
+	})
+
+	registerStrategy("OPTIMAL-SIZE", func(conf *strategyConfig, env *strategyEnv) strategy {
+		capacity := conf.mustInt64("capacity")
+		return newOptimal(conf.getName("OPTIMAL-SIZE-"+strconv.FormatInt(capacity, 10)), env, capacity, true)
+	})
+}
+
+func newOptimal(name string, env *strategyEnv, capacity int64, sizeAware bool) *optimalCache {
+	// without a capacity, nothing is ever evicted and SATELLITE is already optimal
+	if capacity <= 0 {
+		panic(name + ": capacity must be positive")
+	}
+
+	C := &optimalCache{
+		name:          name,
+		constellation: env.constellation,
+		sizeAware:     sizeAware,
+	}
+
+	C.cache = newNodeCachesWithPolicy(capacity, func(capacity int64, rng *rand.Rand) evictionPolicy {
+		return &beladyPolicy{
+			oracle:  C,
+			entries: beladyHeap{},
+			elems:   make(map[int64]*beladyEntry),
+		}
+	}, env.itemSizes)
+
+	// plans are shared by all oracles of a run, so that the requests are only read once, e.g., in a sweep over capacities
+	if env.optimalPlan == nil {
+		env.optimalPlan = planOptimal(env)
+	}
+
+	C.plan = env.optimalPlan.reader()
+
+	return C
+}
+
+// optimalPlan is what the oracle knows about the requests of a run, see optimalCache
+// keeping every request of a long run in memory is not feasible, so it is a temporary file with one block per step
+// blocks go from the last step to the first, so that it can be written in one pass backwards through the steps
+// a block has the distance to the next use of each request of its step, in order and 0 if there is none,
+// followed by the length of the block, so that the blocks can be read from the end of the file
+type optimalPlan struct {
+	file *os.File
+	size int64
+}
+
+// optimalReader goes through the blocks of a plan, from the first step to the last
+type optimalReader struct {
+	plan *optimalPlan
+	// end of the block of the next step
+	end int64
+}
+
+// planOptimal reads the requests of all steps backwards and finds out when each item is needed again at each satellite
+// it only keeps the last use of each item at each satellite in memory
+func planOptimal(env *strategyEnv) *optimalPlan {
+	type use struct {
+		sat  int64
+		item int64
+	}
+
+	f, err := ioutil.TempFile("", "optimal")
+
+	if err != nil {
+		panic(err)
+	}
+
+	// the file is deleted once the run ends, we keep it open until then
+	if err := os.Remove(f.Name()); err != nil {
+		panic(err)
+	}
+
+	P := &optimalPlan{
+		file: f,
+	}
+
+	buf := bufio.NewWriter(f)
+
+	// requests are counted from the end of the run, the distance between two requests is the same either way
+	nextUse := make(map[use]int64)
+	var later int64
+
+	block := []byte{}
+	n := make([]byte, binary.MaxVarintLen64)
+
+	for time := (env.steps - 1) * env.stepLength; time >= 0; time -= env.stepLength {
+		requests := *getRequests(env.resultFiles+strconv.FormatInt(time, 10)+"paths", env.numRequests, time, env.itemVersions)
+
+		// index of a request counted from the end of the run
+		index := func(i int) int64 {
+			return later + int64(len(requests)-1-i)
+		}
+
+		block = block[:0]
+
+		for i, req := range requests {
+			var d int64
+
+			if u, ok := nextUse[use{req.path[1], req.item}]; ok {
+				d = index(i) - u
+			}
+
+			block = append(block, n[:binary.PutUvarint(n, uint64(d))]...)
+		}
+
+		// the first request in this step is the next use for earlier steps
+		for i := len(requests) - 1; i >= 0; i-- {
+			nextUse[use{requests[i].path[1], requests[i].item}] = index(i)
+		}
+
+		later += int64(len(requests))
+
+		binary.LittleEndian.PutUint64(n, uint64(len(block)))
+
+		buf.Write(block)
+		buf.Write(n[:8])
+
+		P.size += int64(len(block)) + 8
+	}
+
+	if err := buf.Flush(); err != nil {
+		panic(err)
+	}
+
+	return P
+}
+
+func (P *optimalPlan) reader() *optimalReader {
+	return &optimalReader{
+		plan: P,
+		end:  P.size,
+	}
+}
+
+// step returns the distances to the next use of the requests of the next step
+func (R *optimalReader) step() []int64 {
+	if R.end <= 0 {
+		panic("no more steps in the plan")
+	}
+
+	n := make([]byte, 8)
+
+	if _, err := R.plan.file.ReadAt(n, R.end-8); err != nil {
+		panic(err)
+	}
+
+	length := int64(binary.LittleEndian.Uint64(n))
+	block := make([]byte, length)
+
+	if _, err := R.plan.file.ReadAt(block, R.end-8-length); err != nil {
+		panic(err)
+	}
+
+	R.end -= 8 + length
+
+	distances := []int64{}
+
+	for len(block) > 0 {
+		d, k := binary.Uvarint(block)
+
+		if k <= 0 {
+			panic("broken plan")
+		}
+
+		distances = append(distances, int64(d))
+		block = block[k:]
+	}
+
+	return distances
+}
+
+func (C *optimalCache) getName() string {
+	return C.name
+}
+
+func (C *optimalCache) getStoreNodes() int64 {
+	return C.constellation.numSats()
+}
+
+func (C *optimalCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
+
+// the plan is made again when a run is continued, so we only need to know where we are
+func (C *optimalCache) save(w *checkpointWriter) {
+	w.write(C.current)
+	w.write(C.plan.end)
+	C.cache.save(w)
+}
+
+func (C *optimalCache) load(r *checkpointReader) {
+	r.read(&C.current)
+	r.read(&C.plan.end)
+	C.cache.load(r)
+}
+
+func (C *optimalCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
+
+	distances := C.plan.step()
+
+	if len(distances) != len(*requests) {
+		panic(C.name + ": requests differ from the ones read in advance")
+	}
+
+	for i, req := range *requests {
+		C.next = noNextUse
+		if distances[i] > 0 {
+			C.next = C.current + distances[i]
+		}
+
+		firstSat := req.path[1]
+
+		// the item is either in the first satellite or we have to go all the way to the origin
+		servedBy := len(req.path) - 1
+		// we do not care whether a copy is outdated, the oracle only knows when items are requested
+		version, success := scache[firstSat][req.item]
+
+		if success {
+			servedBy = 1
+		}
+
+		records.served(req, servedBy, success && version != req.version)
+
+		// the item might also have been added earlier in this step, in which case we update when it is needed next
+		C.cache.hit(firstSat, req.item)
+
+		// no need to cache anything that is never requested here again
+		// a copy that served the request stays as it is, even if it is outdated
+		if !success && C.next != noNextUse {
+			C.cache.add(firstSat, req.item, req.version)
+		}
+
+		C.current++
+	}
+
+	return records.result(C.cache.getStore())
+
+}
+
+func (P *beladyPolicy) add(item int64, size int64) {
+	e := &beladyEntry{
+		item:    item,
+		size:    size,
+		nextUse: P.oracle.next,
+	}
+
+	P.elems[item] = e
+	heap.Push(&P.entries, e)
+}
+
+func (P *beladyPolicy) hit(item int64) {
+	e, ok := P.elems[item]
+
+	if !ok {
+		return
+	}
+
+	e.nextUse = P.oracle.next
+	heap.Fix(&P.entries, e.index)
+}
+
+func (P *beladyPolicy) remove(item int64) {
+	e, ok := P.elems[item]
+
+	if !ok {
+		return
+	}
+
+	heap.Remove(&P.entries, e.index)
+	delete(P.elems, item)
+}
+
+func (P *beladyPolicy) evict() int64 {
+	victim := 0
+
+	// the cost of keeping an item changes with every request, so we have to look at all of them
+	if P.oracle.sizeAware {
+		for i, e := range P.entries {
+			if P.cost(e) > P.cost(P.entries[victim]) || (P.cost(e) == P.cost(P.entries[victim]) && P.entries.Less(i, victim)) {
+				victim = i
+			}
+		}
+	}
+
+	e := heap.Remove(&P.entries, victim).(*beladyEntry)
+	delete(P.elems, e.item)
+	return e.item
+}
+
+// beladyEntryState is how an entry is stored in a checkpoint
+type beladyEntryState struct {
+	Item    int64
+	Size    int64
+	NextUse int64
+}
+
+// entries are saved in the order of the heap, so that the size-aware variant breaks ties the same way after loading
+func (P *beladyPolicy) save(w *checkpointWriter) {
+	entries := make([]beladyEntryState, len(P.entries))
+
+	for i, e := range P.entries {
+		entries[i] = beladyEntryState{
+			Item:    e.item,
+			Size:    e.size,
+			NextUse: e.nextUse,
+		}
+	}
+
+	w.write(entries)
+}
+
+func (P *beladyPolicy) load(r *checkpointReader) {
+	var entries []beladyEntryState
+	r.read(&entries)
+
+	for i, s := range entries {
+		e := &beladyEntry{
+			item:    s.Item,
+			size:    s.Size,
+			nextUse: s.NextUse,
+			index:   i,
+		}
+
+		P.elems[e.item] = e
+		P.entries = append(P.entries, e)
+	}
+}
+
+// cost of keeping an item is the space it takes up until it is needed again
+func (P *beladyPolicy) cost(e *beladyEntry) float64 {
+	if e.nextUse == noNextUse {
+		return math.Inf(1)
+	}
+
+	return float64(e.nextUse-P.oracle.current) * float64(e.size)
+}
+
+// the item needed furthest in the future is on top
+func (h beladyHeap) Len() int {
+	return len(h)
+}
+
+func (h beladyHeap) Less(i, j int) bool {
+	if h[i].nextUse == h[j].nextUse {
+		return h[i].item > h[j].item
+	}
+
+	return h[i].nextUse > h[j].nextUse
+}
+
+func (h beladyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *beladyHeap) Push(x interface{}) {
+	e := x.(*beladyEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *beladyHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,83 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// writeOrigins writes the following to file, all in bytes
+// * total egress of all origins, i.e., bytes of requests that were not served from a cache
+// * bytes saved compared to NONE, which serves every request from its origin
+// * egress per origin node that had requests in this step
+func writeOrigins(filename string, records *[]cacheRecord) {
+	var total, egress int64
+	perOrigin := make(map[int64]int64)
+
+	for _, r := range *records {
+		total += r.bytes
+
+		// origins of items that are only served from caches still get a zero
+		if r.success {
+			perOrigin[r.origin] += 0
+			continue
+		}
+
+		egress += r.bytes
+		perOrigin[r.origin] += r.bytes
+	}
+
+	origins := make([]int64, 0, len(perOrigin))
+
+	for o := range perOrigin {
+		origins = append(origins, o)
+	}
+
+	sort.Slice(origins, func(i, j int) bool { return origins[i] < origins[j] })
+
+	originsFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer originsFile.Close()
+
+	buf := bufio.NewWriter(originsFile)
+
+	buf.WriteString("egress,")
+	buf.WriteString(strconv.FormatInt(egress, 10))
+	buf.WriteString("\n")
+
+	buf.WriteString("bytes_saved,")
+	buf.WriteString(strconv.FormatInt(total-egress, 10))
+	buf.WriteString("\n")
+
+	for _, o := range origins {
+		buf.WriteString("egress_")
+		buf.WriteString(strconv.FormatInt(o, 10))
+		buf.WriteString(",")
+		buf.WriteString(strconv.FormatInt(perOrigin[o], 10))
+		buf.WriteString("\n")
+	}
+
+	buf.Flush()
+}
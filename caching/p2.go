@@ -0,0 +1,176 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "sort"
+
+// the P-square algorithm keeps five markers per quantile
+const p2Markers = 5
+
+// p2Quantile estimates a quantile of a stream of observations with the P-square algorithm (Jain and Chlamtac, CACM '85)
+// it follows github.com/narqo/psqr, but its state is exported so that it can be written to a checkpoint
+type p2Quantile struct {
+	P      float64
+	Filled bool
+	// marker positions
+	Pos [p2Markers]int
+	// desired marker positions and their increments
+	NPos [p2Markers]float64
+	DN   [p2Markers]float64
+	// marker heights, the first observations until there are enough for all markers
+	Heights []float64
+}
+
+func newP2Quantile(p float64) *p2Quantile {
+	if p < 0 || p > 1 {
+		panic("p-quantile is out of range")
+	}
+
+	q := &p2Quantile{
+		P:       p,
+		Heights: make([]float64, 0, p2Markers),
+	}
+
+	for i := 0; i < p2Markers; i++ {
+		q.Pos[i] = i
+	}
+
+	q.NPos = [p2Markers]float64{0, 2 * p, 4 * p, 2 + 2*p, 4}
+	q.DN = [p2Markers]float64{0, p / 2, p, (1 + p) / 2, 1}
+
+	return q
+}
+
+// copy gives an estimator with the same state that can be changed independently
+func (q *p2Quantile) copy() *p2Quantile {
+	c := *q
+	c.Heights = append(make([]float64, 0, p2Markers), q.Heights...)
+	return &c
+}
+
+// append adds an observation
+func (q *p2Quantile) append(v float64) {
+	if len(q.Heights) != p2Markers {
+		q.Heights = append(q.Heights, v)
+		return
+	}
+
+	if !q.Filled {
+		q.Filled = true
+		sort.Float64s(q.Heights)
+	}
+
+	l := len(q.Heights) - 1
+
+	// find the cell of the observation and extend the extreme markers if necessary
+	k := -1
+	if v < q.Heights[0] {
+		k = 0
+		q.Heights[0] = v
+	} else if q.Heights[l] <= v {
+		k = l - 1
+		q.Heights[l] = v
+	} else {
+		for i := 1; i <= l; i++ {
+			if q.Heights[i-1] <= v && v < q.Heights[i] {
+				k = i - 1
+				break
+			}
+		}
+	}
+
+	for i := 0; i < p2Markers; i++ {
+		if i > k {
+			q.Pos[i]++
+		}
+
+		q.NPos[i] += q.DN[i]
+	}
+
+	q.adjust()
+}
+
+// adjust moves the middle markers towards their desired positions
+func (q *p2Quantile) adjust() {
+	for i := 1; i < len(q.Heights)-1; i++ {
+		n := q.Pos[i]
+		np1 := q.Pos[i+1]
+		nm1 := q.Pos[i-1]
+
+		d := q.NPos[i] - float64(n)
+
+		if (d >= 1 && np1-n > 1) || (d <= -1 && nm1-n < -1) {
+			if d >= 0 {
+				d = 1
+			} else {
+				d = -1
+			}
+
+			h := q.Heights[i]
+			hp1 := q.Heights[i+1]
+			hm1 := q.Heights[i-1]
+
+			// piecewise parabolic prediction, or linear if that is not monotonic
+			hi := p2Parabolic(d, hp1, h, hm1, float64(np1), float64(n), float64(nm1))
+
+			if hm1 < hi && hi < hp1 {
+				q.Heights[i] = hi
+			} else {
+				hd := q.Heights[i+int(d)]
+				nd := q.Pos[i+int(d)]
+				q.Heights[i] = h + d*(hd-h)/float64(nd-n)
+			}
+
+			q.Pos[i] += int(d)
+		}
+	}
+}
+
+// value is the current estimate
+// with fewer observations than markers, it is taken from the sorted observations
+func (q *p2Quantile) value() float64 {
+	if !q.Filled {
+		l := len(q.Heights)
+
+		switch l {
+		case 0:
+			return 0
+		case 1:
+			return q.Heights[0]
+		}
+
+		sort.Float64s(q.Heights)
+
+		// the maximum for p = 1
+		i := int(q.P * float64(l))
+		if i > l-1 {
+			i = l - 1
+		}
+
+		return q.Heights[i]
+	}
+
+	return q.Heights[2]
+}
+
+func p2Parabolic(d, qp1, q, qm1, np1, n, nm1 float64) float64 {
+	a := d / (np1 - nm1)
+	b1 := (n - nm1 + d) * (qp1 - q) / (np1 - n)
+	b2 := (np1 - n - d) * (q - qm1) / (n - nm1)
+	return q + a*(b1+b2)
+}
@@ -0,0 +1,51 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "testing"
+
+// before there are enough observations for all markers, the estimate is taken from the observations themselves
+func TestP2QuantileWithFewObservations(t *testing.T) {
+	observations := []float64{5, 3, 4, 1, 2}
+
+	for n := 0; n <= len(observations); n++ {
+		min, max := 0.0, 0.0
+
+		for i, v := range observations[:n] {
+			if i == 0 || v < min {
+				min = v
+			}
+
+			if i == 0 || v > max {
+				max = v
+			}
+		}
+
+		for p, want := range map[float64]float64{0: min, 1: max} {
+			q := newP2Quantile(p)
+
+			for _, v := range observations[:n] {
+				q.append(v)
+			}
+
+			if got := q.value(); got != want {
+				t.Errorf("p = %v with %d observations: got %v, want %v", p, n, got, want)
+			}
+		}
+	}
+}
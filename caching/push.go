@@ -0,0 +1,357 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// pushCache places the most popular items on every satellite before the first step, like a push CDN
+// satellites never cache anything on their own, a request is either served by the first satellite or by the origin
+// placement can be refreshed regularly, then the items that were requested most since the last placement are placed
+// and placed items that have changed since are pushed again
+type pushCache struct {
+	name           string
+	constellation  *constellation
+	cache          *nodeCaches
+	itemSizes      map[int64]int64
+	itemPopularity map[int64]float64
+	itemOrigins    map[int64]int64
+	itemVersions   *itemVersions
+	// either the number of items to place or the bytes that can be placed on each satellite
+	numItems int64
+	budget   int64
+	// seconds between placements, 0 means items are only placed once
+	refresh int64
+
+	placed map[int64]struct{}
+	// when the items were placed last
+	lastPlaced int64
+	// requests per item since the last placement
+	requests map[int64]int64
+	// links over which an item from an origin is distributed, per uplink satellite
+	trees map[int64][][2]int64
+
+	metrics []metric
+}
+
+func init() {
+	registerStrategy("PUSH", func(conf *strategyConfig, env *strategyEnv) strategy {
+		numItems := conf.getInt64("items", 0)
+		budget := conf.getInt64("budget", 0)
+		refresh := conf.getInt64("refresh", 0)
+
+		if numItems < 0 || budget < 0 || refresh < 0 {
+			panic("PUSH: items, budget, and refresh must not be negative")
+		}
+
+		if (numItems > 0) == (budget > 0) {
+			panic("PUSH: exactly one of items and budget must be set")
+		}
+
+		name := "PUSH-" + strconv.FormatInt(numItems, 10) + "ITEMS"
+		if budget > 0 {
+			name = "PUSH-" + strconv.FormatInt(budget, 10) + "B"
+		}
+
+		if refresh > 0 {
+			name += "-REFRESH-" + strconv.FormatInt(refresh, 10)
+		}
+
+		return newPush(conf.getName(name), env.constellation, numItems, budget, refresh, env.itemSizes, getItemPopularity(env.loadFile), getItemOrigins(env.loadFile, env.locationFile), env.itemVersions)
+	})
+}
+
+func newPush(name string, constellation *constellation, numItems int64, budget int64, refresh int64, itemSizes *map[int64]int64, itemPopularity *map[int64]float64, itemOrigins *map[int64]int64, itemVersions *itemVersions) *pushCache {
+	return &pushCache{
+		name:           name,
+		constellation:  constellation,
+		cache:          newNodeCachesWithPolicy(0, nil, itemSizes),
+		itemSizes:      *itemSizes,
+		itemPopularity: *itemPopularity,
+		itemOrigins:    *itemOrigins,
+		itemVersions:   itemVersions,
+		numItems:       numItems,
+		budget:         budget,
+		refresh:        refresh,
+		requests:       make(map[int64]int64),
+		trees:          make(map[int64][][2]int64),
+	}
+}
+
+func (C *pushCache) getName() string {
+	return C.name
+}
+
+func (C *pushCache) getStoreNodes() int64 {
+	return C.constellation.numSats()
+}
+
+func (C *pushCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
+
+func (C *pushCache) getMetrics() *[]metric {
+	return &C.metrics
+}
+
+// choose the items to place: most requested since the last placement first, then the most popular ones
+func (C *pushCache) choose() map[int64]struct{} {
+	items := make([]int64, 0, len(C.itemPopularity))
+
+	for item := range C.itemPopularity {
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if C.requests[items[i]] != C.requests[items[j]] {
+			return C.requests[items[i]] > C.requests[items[j]]
+		}
+
+		if C.itemPopularity[items[i]] != C.itemPopularity[items[j]] {
+			return C.itemPopularity[items[i]] > C.itemPopularity[items[j]]
+		}
+
+		return items[i] < items[j]
+	})
+
+	chosen := make(map[int64]struct{})
+
+	if C.numItems > 0 {
+		for i := 0; i < len(items) && int64(i) < C.numItems; i++ {
+			chosen[items[i]] = struct{}{}
+		}
+
+		return chosen
+	}
+
+	// fill up the budget, smaller items can still fit when a larger one does not
+	var used int64
+
+	for _, item := range items {
+		if used+C.itemSizes[item] > C.budget {
+			continue
+		}
+
+		chosen[item] = struct{}{}
+		used += C.itemSizes[item]
+	}
+
+	return chosen
+}
+
+// place the chosen items on all satellites
+// new and changed items are uplinked by their origin and forwarded to all satellites over ISLs
+func (C *pushCache) place(time int64, gndSatLinks *map[int64]gndSatLink, txRecords *[]txRecord) (int64, int64) {
+	chosen := C.choose()
+
+	// items that are missing or outdated on at least one satellite
+	var pushed []int64
+	// satellites that need each of them
+	targets := make(map[int64][]int64)
+	var dropped int64
+
+	for item := range chosen {
+		current := C.itemVersions.version(item, time)
+
+		// satellites can lose items on their own, e.g., when they fail, so each one is checked
+		for sat := int64(0); sat < C.constellation.numSats(); sat++ {
+			if C.cache.isDown(sat) {
+				continue
+			}
+
+			if v, ok := C.cache.version(sat, item); !ok || v != current {
+				targets[item] = append(targets[item], sat)
+			}
+		}
+
+		if len(targets[item]) > 0 {
+			pushed = append(pushed, item)
+		}
+	}
+
+	for item := range C.placed {
+		if _, ok := chosen[item]; ok {
+			continue
+		}
+
+		for sat := int64(0); sat < C.constellation.numSats(); sat++ {
+			C.cache.remove(sat, item)
+		}
+
+		dropped++
+	}
+
+	sort.Slice(pushed, func(i, j int) bool {
+		return pushed[i] < pushed[j]
+	})
+
+	for _, item := range pushed {
+		origin := C.itemOrigins[item]
+
+		uplink, ok := (*gndSatLinks)[origin]
+
+		if !ok {
+			panic(C.name + ": no satellite link for origin " + strconv.FormatInt(origin, 10))
+		}
+
+		tree, ok := C.trees[uplink.sat]
+
+		if !ok {
+			tree = C.constellation.distributionTree(uplink.sat)
+			C.trees[uplink.sat] = tree
+		}
+
+		*txRecords = append(*txRecords, txRecord{
+			source:    origin,
+			target:    uplink.sat,
+			bandwidth: C.itemSizes[item],
+			kind:      txPlacement,
+		})
+
+		for _, l := range prune(tree, targets[item]) {
+			*txRecords = append(*txRecords, txRecord{
+				source:    l[0],
+				target:    l[1],
+				bandwidth: C.itemSizes[item],
+				kind:      txPlacement,
+			})
+		}
+
+		for _, sat := range targets[item] {
+			C.cache.add(sat, item, C.itemVersions.version(item, time))
+		}
+	}
+
+	C.placed = chosen
+	C.requests = make(map[int64]int64)
+
+	return int64(len(pushed)), dropped
+}
+
+// prune keeps only the links of a distribution tree that lead to one of the targets, in the same order
+func prune(tree [][2]int64, targets []int64) [][2]int64 {
+	needed := make(map[int64]bool, len(targets))
+
+	for _, t := range targets {
+		needed[t] = true
+	}
+
+	used := make([]bool, len(tree))
+
+	// links are ordered from the root outwards, so children are done before their parents
+	for i := len(tree) - 1; i >= 0; i-- {
+		if needed[tree[i][1]] {
+			used[i] = true
+			needed[tree[i][0]] = true
+		}
+	}
+
+	links := make([][2]int64, 0, len(tree))
+
+	for i, l := range tree {
+		if used[i] {
+			links = append(links, l)
+		}
+	}
+
+	return links
+}
+
+// pushState is how the placement is stored in a checkpoint
+type pushState struct {
+	// nothing has been placed yet if this is false
+	Placed     bool
+	LastPlaced int64
+	Items      []int64
+	Requests   map[int64]int64
+}
+
+// distribution trees are computed again when they are needed
+func (C *pushCache) save(w *checkpointWriter) {
+	s := pushState{
+		Placed:     C.placed != nil,
+		LastPlaced: C.lastPlaced,
+		Requests:   C.requests,
+	}
+
+	for item := range C.placed {
+		s.Items = append(s.Items, item)
+	}
+
+	w.write(s)
+	C.cache.save(w)
+}
+
+func (C *pushCache) load(r *checkpointReader) {
+	var s pushState
+	r.read(&s)
+
+	C.lastPlaced = s.LastPlaced
+
+	if s.Placed {
+		C.placed = make(map[int64]struct{}, len(s.Items))
+
+		for _, item := range s.Items {
+			C.placed[item] = struct{}{}
+		}
+	}
+
+	for item, n := range s.Requests {
+		C.requests[item] = n
+	}
+
+	C.cache.load(r)
+}
+
+func (C *pushCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	var pushed, dropped int64
+
+	// items are placed before the requests of a step arrive
+	// steps need not line up with the refresh interval, so placement is repeated in the first step after it has passed
+	if C.placed == nil || (C.refresh > 0 && time-C.lastPlaced >= C.refresh) {
+		pushed, dropped = C.place(time, gndSatLinks, &records.tx)
+		C.lastPlaced = time
+	}
+
+	for _, req := range *requests {
+		C.requests[req.item]++
+
+		// the item is either in the first satellite or we have to go all the way to the origin
+		servedBy := len(req.path) - 1
+		version, success := C.cache.version(req.path[1], req.item)
+
+		if success {
+			servedBy = 1
+		}
+
+		records.served(req, servedBy, success && version != req.version)
+	}
+
+	C.metrics = []metric{
+		{name: "pushed_items", value: float64(pushed)},
+		{name: "dropped_items", value: float64(dropped)},
+	}
+
+	return records.result(C.cache.getStore())
+
+}
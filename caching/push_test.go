@@ -0,0 +1,43 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "testing"
+
+// placement is refreshed in the first step after refresh seconds have passed, even if steps do not line up with it
+func TestPushRefreshWithOtherStepLength(t *testing.T) {
+	sizes := map[int64]int64{1: 100}
+	// item 1 changes every 10 seconds, so every placement has to push it again
+	intervals := map[int64]int64{1: 10}
+
+	C := newPush("PUSH", &constellation{planes: 2, satsPerPlane: 2}, 1, 0, 25, &sizes, &map[int64]float64{1: 1}, &map[int64]int64{1: -1}, newItemVersions(&intervals))
+
+	shortestSatPaths := map[int64]map[int64]satPath{}
+	gndSatLinks := map[int64]gndSatLink{-1: {sat: 0}}
+	requests := []*request{}
+
+	want := map[int64]bool{0: true, 30: true, 60: true}
+
+	for time := int64(0); time < 70; time += 10 {
+		tx, _, _, _, _ := C.stepTo(time, &shortestSatPaths, &gndSatLinks, &requests)
+
+		if placed := len(*tx) > 0; placed != want[time] {
+			t.Errorf("time %d: placed %t, want %t", time, placed, want[time])
+		}
+	}
+}
@@ -25,7 +25,8 @@ import (
 	"strings"
 )
 
-func getRequests(pathFile string, numRequests int) *[]*request {
+// getRequests reads the requests of a step, versions are those of the items at the time of the step
+func getRequests(pathFile string, numRequests int, time int64, versions *itemVersions) *[]*request {
 	r, err := os.Open(pathFile)
 
 	if err != nil {
@@ -76,6 +77,7 @@ func getRequests(pathFile string, numRequests int) *[]*request {
 			item:      item,
 			bandwidth: size,
 			path:      *strToInt64(&path),
+			version:   versions.version(item, time),
 		})
 	}
 
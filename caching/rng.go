@@ -0,0 +1,78 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "math/rand"
+
+// seededRand is a random number generator whose state can be written to a checkpoint
+// the state of a rand.Source cannot be read, so it uses a splitmix64 source, whose whole state is one number
+type seededRand struct {
+	*rand.Rand
+	src *splitMix64
+}
+
+// splitMix64 is the generator by Steele et al. (OOPSLA '14) that is also used to seed xoroshiro
+type splitMix64 struct {
+	state uint64
+}
+
+// seededRandState is how a seededRand is stored in a checkpoint
+type seededRandState struct {
+	State uint64
+}
+
+func newSeededRand(seed int64) *seededRand {
+	src := &splitMix64{}
+	src.Seed(seed)
+
+	return &seededRand{
+		Rand: rand.New(src),
+		src:  src,
+	}
+}
+
+func (s *splitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+func (s *splitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *splitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (r *seededRand) save(w *checkpointWriter) {
+	w.write(seededRandState{
+		State: r.src.state,
+	})
+}
+
+func (r *seededRand) load(cr *checkpointReader) {
+	var state seededRandState
+	cr.read(&state)
+
+	r.src.state = state.State
+}
@@ -0,0 +1,199 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// values that are kept over the whole run
+// hops and latency are per request, flow and store per node and step
+var runValues = []string{"hops", "latency", "flow", "store"}
+
+// runStats estimates the distribution of one value over the whole run without keeping the observations
+// percentiles use the P-square algorithm, which keeps five markers per percentile
+// everything is exported so that it can be written to a checkpoint
+type runStats struct {
+	Count  int64
+	Total  float64
+	Max    float64
+	Median *p2Quantile
+	P95    *p2Quantile
+	P99    *p2Quantile
+}
+
+func newRunStats() *runStats {
+	return &runStats{
+		Median: newP2Quantile(0.5),
+		P95:    newP2Quantile(0.95),
+		P99:    newP2Quantile(0.99),
+	}
+}
+
+func (s *runStats) add(v float64) {
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+
+	s.Count++
+	s.Total += v
+	s.Median.append(v)
+	s.P95.append(v)
+	s.P99.append(v)
+}
+
+func (s *runStats) copy() *runStats {
+	return &runStats{
+		Count:  s.Count,
+		Total:  s.Total,
+		Max:    s.Max,
+		Median: s.Median.copy(),
+		P95:    s.P95.copy(),
+		P99:    s.P99.copy(),
+	}
+}
+
+// runSummary has the stats of each value for each strategy
+type runSummary map[string]map[string]*runStats
+
+// copy gives a summary with the same state that can be changed independently
+func (R runSummary) copy() runSummary {
+	c := make(runSummary, len(R))
+
+	for strategy, values := range R {
+		c[strategy] = make(map[string]*runStats, len(values))
+
+		for v, stats := range values {
+			c[strategy][v] = stats.copy()
+		}
+	}
+
+	return c
+}
+
+func (R runSummary) get(strategyName string, value string) *runStats {
+	if _, ok := R[strategyName]; !ok {
+		R[strategyName] = make(map[string]*runStats)
+
+		for _, v := range runValues {
+			R[strategyName][v] = newRunStats()
+		}
+	}
+
+	return R[strategyName][value]
+}
+
+// add adds the values of one step of a strategy
+// nodes without any flow or store count as zero, like in the per-step files
+func (R runSummary) add(strategyName string, hopsRecords *[]hopsRecord, latencies []float64, flowPerSat map[int64]int64, strPerNode map[int64]int64, storeNodes int64) {
+	hops := R.get(strategyName, "hops")
+
+	for _, r := range *hopsRecords {
+		hops.add(float64(r.hops))
+	}
+
+	latency := R.get(strategyName, "latency")
+
+	for _, l := range latencies {
+		latency.add(l)
+	}
+
+	flow := R.get(strategyName, "flow")
+
+	for _, node := range sortedNodes(flowPerSat) {
+		flow.add(float64(flowPerSat[node]))
+	}
+
+	store := R.get(strategyName, "store")
+
+	for _, node := range sortedNodes(strPerNode) {
+		store.add(float64(strPerNode[node]))
+	}
+
+	for i := int64(len(strPerNode)); i < storeNodes; i++ {
+		store.add(0)
+	}
+}
+
+// sortedNodes gives the nodes of a map in order
+// the estimates depend on the order of observations, so runs would differ if we went by map order
+func sortedNodes(perNode map[int64]int64) []int64 {
+	nodes := make([]int64, 0, len(perNode))
+
+	for node := range perNode {
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+
+	return nodes
+}
+
+// write writes one line per strategy and value with the number of observations, avg, median, 95th and 99th pcntl,
+// and max over the whole run
+func (R runSummary) write(filename string) {
+	strategies := make([]string, 0, len(R))
+
+	for s := range R {
+		strategies = append(strategies, s)
+	}
+
+	sort.Strings(strategies)
+
+	summaryFile, err := os.Create(filename)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer summaryFile.Close()
+
+	buf := bufio.NewWriter(summaryFile)
+
+	buf.WriteString("strategy,value,count,avg,median,95th,99th,max\n")
+
+	for _, s := range strategies {
+		for _, v := range runValues {
+			stats := R[s][v]
+
+			buf.WriteString(s)
+			buf.WriteString(",")
+			buf.WriteString(v)
+			buf.WriteString(",")
+			buf.WriteString(strconv.FormatInt(stats.Count, 10))
+
+			// without observations, there is nothing to estimate
+			if stats.Count == 0 {
+				buf.WriteString(",,,,,\n")
+				continue
+			}
+
+			for _, x := range []float64{stats.Total / float64(stats.Count), stats.Median.value(), stats.P95.value(), stats.P99.value(), stats.Max} {
+				buf.WriteString(",")
+				buf.WriteString(strconv.FormatFloat(x, 'f', -1, 64))
+			}
+
+			buf.WriteString("\n")
+		}
+	}
+
+	buf.Flush()
+}
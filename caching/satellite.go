@@ -18,124 +18,94 @@
 package main
 
 type satelliteCache struct {
-	cache map[int64]map[int64]struct{}
+	name          string
+	constellation *constellation
+	cache         *nodeCaches
+	lookup        *satelliteLookup
+	consistency   *cacheConsistency
+
+	metrics []metric
+}
+
+func init() {
+	registerStrategy("SATELLITE", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		lookup := getSatelliteLookup(conf)
+		consistency := getCacheConsistency(conf, env)
+		return newSatellite(conf.getName("SATELLITE"+cacheConf.suffix()+lookup.suffix()+consistency.suffix()), env.constellation, cacheConf, lookup, consistency, env.itemSizes)
+	})
 }
 
-func newSatellite() *satelliteCache {
+func newSatellite(name string, constellation *constellation, cacheConf cacheConfig, lookup *satelliteLookup, consistency *cacheConsistency, itemSizes *map[int64]int64) *satelliteCache {
 	return &satelliteCache{
-		cache: make(map[int64]map[int64]struct{}),
+		name:          name,
+		constellation: constellation,
+		cache:         newNodeCaches(cacheConf, itemSizes),
+		lookup:        lookup,
+		consistency:   consistency,
 	}
 }
 
+func (C *satelliteCache) getMetrics() *[]metric {
+	// without admission control, there is nothing to report
+	if C.metrics == nil {
+		return nil
+	}
+
+	return &C.metrics
+}
+
 func (C *satelliteCache) getName() string {
-	return "SATELLITE"
+	return C.name
 }
 
 func (C *satelliteCache) getStoreNodes() int64 {
-	return 66 * 24
+	return C.constellation.numSats()
 }
 
-func (C *satelliteCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord) {
-
-	txRecords := []txRecord{}
-	storeRecords := []storeRecord{}
-	// we always need as many cache records as we have requests
-	cacheRecords := make([]cacheRecord, 0, len(*requests))
-	// same goes for hops records
-	hopsRecords := make([]hopsRecord, 0, len(*requests))
-
-	// prepare a copied cache so we can modify the real cache
-	scache := copyCache(C.cache)
-
-	for _, req := range *requests {
-		// hops := int64(0)
-		// success := false
-
-		// for i := 0; i < len(req.path)-1; i++ {
-
-		// 	source := req.path[i]
-		// 	target := req.path[i+1]
-
-		// 	if _, ok := scache[source]; ok {
-		// 		if _, ok := scache[source][req.item]; ok {
-		// 			success = true
-		// 			break
-		// 		}
-		// 	}
-
-		// 	txRecords = append(txRecords, txRecord{
-		// 		source:    source,
-		// 		target:    target,
-		// 		bandwidth: req.bandwidth,
-		// 	})
-
-		// 	hops++
-		// }
-
-		// firstSat := req.path[1]
-
-		hops := int64(0)
-		success := false
+func (C *satelliteCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
 
-		// check if the satellite that got  the request first has the item in cache
-		firstSat := req.path[1]
-		if _, ok := scache[firstSat]; ok {
-			if _, ok := scache[firstSat][req.item]; ok {
-				txRecords = append(txRecords, txRecord{
-					source:    req.path[0],
-					target:    firstSat,
-					bandwidth: req.bandwidth,
-				})
+func (C *satelliteCache) save(w *checkpointWriter) {
+	C.lookup.save(w)
+	C.cache.save(w)
+}
 
-				hops = 1
+func (C *satelliteCache) load(r *checkpointReader) {
+	C.lookup.load(r)
+	C.cache.load(r)
+}
 
-				success = true
-			}
-		}
+func (C *satelliteCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
 
-		// if it didn't: request to origin server
-		if !success {
-			for i := 0; i < len(req.path)-1; i++ {
-				source := req.path[i]
-				target := req.path[i+1]
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
 
-				txRecords = append(txRecords, txRecord{
-					source:    source,
-					target:    target,
-					bandwidth: req.bandwidth,
-				})
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx)
 
-				hops++
-			}
-		}
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
 
-		cacheRecords = append(cacheRecords, cacheRecord{
-			item:    req.item,
-			success: success,
-		})
+	for _, req := range *requests {
+		// find the satellite that has the item in cache, or go all the way to the origin
+		servedBy := C.lookup.find(req, scache, C.consistency)
+		success := servedBy < len(req.path)-1
+		stale := success && scache[req.path[servedBy]][req.item] != req.version
 
-		hopsRecords = append(hopsRecords, hopsRecord{
-			item: req.item,
-			hops: hops,
-		})
+		records.served(req, servedBy, stale)
 
-		// write that item into the cache for the next round
-		if _, ok := C.cache[firstSat]; !ok {
-			C.cache[firstSat] = make(map[int64]struct{})
+		if success {
+			C.cache.hit(req.path[servedBy], req.item)
+			C.consistency.validate(req.path[servedBy], req, shortestSatPaths, gndSatLinks, &records.tx)
 		}
 
-		C.cache[firstSat][req.item] = struct{}{}
+		// write that item into the caches for the next round
+		C.lookup.insert(req, servedBy, C.cache)
 	}
 
-	for node := range C.cache {
-		for item := range C.cache[node] {
-			storeRecords = append(storeRecords, storeRecord{
-				node: node,
-				item: item,
-			})
-		}
-	}
+	C.metrics = C.cache.admissionMetrics()
 
-	return &txRecords, &storeRecords, &cacheRecords, &hopsRecords
+	return records.result(C.cache.getStore())
 
 }
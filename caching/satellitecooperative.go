@@ -0,0 +1,270 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// satelliteCooperativeCache is like satelliteCache, but on a miss the first satellite asks the
+// satellites around it before going to the origin
+// the neighborhood is either all satellites within a number of ISL hops or within a distance
+type satelliteCooperativeCache struct {
+	name          string
+	constellation *constellation
+	cache         *nodeCaches
+	consistency   *cacheConsistency
+	// maximum ISL hops to a neighbor, used if maxDistance is 0
+	maxHops int64
+	// maximum path distance to a neighbor
+	maxDistance int64
+
+	metrics []metric
+}
+
+type satelliteNeighbor struct {
+	sat      int64
+	path     []int64
+	distance int64
+}
+
+func init() {
+	registerStrategy("SATELLITE-COOPERATIVE", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		consistency := getCacheConsistency(conf, env)
+
+		maxHops := conf.getInt64("radius_hops", 1)
+		maxDistance := conf.getInt64("radius_distance", 0)
+
+		if maxHops < 0 || maxDistance < 0 {
+			panic("SATELLITE-COOPERATIVE: radius must not be negative")
+		}
+
+		radius := "-" + strconv.FormatInt(maxHops, 10) + "HOPS"
+		if maxDistance > 0 {
+			radius = "-" + strconv.FormatInt(maxDistance, 10) + "M"
+		}
+
+		return newSatelliteCooperative(conf.getName("SATELLITE-COOPERATIVE"+radius+cacheConf.suffix()+consistency.suffix()), env.constellation, cacheConf, consistency, maxHops, maxDistance, env.itemSizes)
+	})
+}
+
+func newSatelliteCooperative(name string, constellation *constellation, cacheConf cacheConfig, consistency *cacheConsistency, maxHops int64, maxDistance int64, itemSizes *map[int64]int64) *satelliteCooperativeCache {
+	return &satelliteCooperativeCache{
+		name:          name,
+		constellation: constellation,
+		cache:         newNodeCaches(cacheConf, itemSizes),
+		consistency:   consistency,
+		maxHops:       maxHops,
+		maxDistance:   maxDistance,
+	}
+}
+
+func (C *satelliteCooperativeCache) getName() string {
+	return C.name
+}
+
+func (C *satelliteCooperativeCache) getStoreNodes() int64 {
+	return C.constellation.numSats()
+}
+
+func (C *satelliteCooperativeCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
+
+func (C *satelliteCooperativeCache) getMetrics() *[]metric {
+	return &C.metrics
+}
+
+func (C *satelliteCooperativeCache) inRadius(p satPath) bool {
+	if C.maxDistance > 0 {
+		return p.distance <= C.maxDistance
+	}
+
+	return int64(len(*p.path))-1 <= C.maxHops
+}
+
+// getNeighbors finds all satellites in the radius of each satellite, closest ones first
+// we only know paths between satellites that serve ground stations, but those are the only ones that cache anything
+func (C *satelliteCooperativeCache) getNeighbors(shortestSatPaths *map[int64]map[int64]satPath) map[int64][]satelliteNeighbor {
+	neighbors := make(map[int64][]satelliteNeighbor)
+
+	for from, paths := range *shortestSatPaths {
+		for to := range paths {
+			if from == to {
+				continue
+			}
+
+			// paths are only stored in one direction
+			for _, pair := range [][2]int64{{from, to}, {to, from}} {
+				p, ok := getSatPath(shortestSatPaths, pair[0], pair[1])
+
+				if !ok || !C.inRadius(p) {
+					continue
+				}
+
+				neighbors[pair[0]] = append(neighbors[pair[0]], satelliteNeighbor{
+					sat:      pair[1],
+					path:     *p.path,
+					distance: p.distance,
+				})
+			}
+		}
+	}
+
+	for sat := range neighbors {
+		n := neighbors[sat]
+
+		sort.Slice(n, func(i, j int) bool {
+			if len(n[i].path) != len(n[j].path) {
+				return len(n[i].path) < len(n[j].path)
+			}
+
+			if n[i].distance != n[j].distance {
+				return n[i].distance < n[j].distance
+			}
+
+			return n[i].sat < n[j].sat
+		})
+	}
+
+	return neighbors
+}
+
+func (C *satelliteCooperativeCache) save(w *checkpointWriter) {
+	C.cache.save(w)
+}
+
+func (C *satelliteCooperativeCache) load(r *checkpointReader) {
+	C.cache.load(r)
+}
+
+func (C *satelliteCooperativeCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	// neighborhoods change as satellites move
+	neighbors := C.getNeighbors(shortestSatPaths)
+
+	var lookupMessages int64
+	var localHits int64
+	var neighborHits int64
+
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx)
+
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
+
+	for _, req := range *requests {
+		hops := int64(0)
+		success := false
+		// version of the copy that serves the request
+		version := req.version
+
+		firstSat := req.path[1]
+
+		// the request always goes to the first satellite
+		records.tx = append(records.tx, txRecord{
+			source:    req.path[0],
+			target:    firstSat,
+			bandwidth: req.bandwidth,
+			req:       req,
+		})
+
+		hops++
+		distance := groundDistance(gndSatLinks, req.path[0])
+
+		if v, ok := scache[firstSat][req.item]; ok && C.consistency.usable(v, req) {
+			success = true
+			version = v
+			localHits++
+
+			C.cache.hit(firstSat, req.item)
+			C.consistency.validate(firstSat, req, shortestSatPaths, gndSatLinks, &records.tx)
+		}
+
+		// ask all neighbors at once, the closest one that has the item sends it
+		if !success {
+			lookupMessages += int64(len(neighbors[firstSat]))
+
+			for _, n := range neighbors[firstSat] {
+				v, ok := scache[n.sat][req.item]
+
+				if !ok || !C.consistency.usable(v, req) {
+					continue
+				}
+
+				for i := 1; i < len(n.path); i++ {
+					records.tx = append(records.tx, txRecord{
+						source:    n.path[i-1],
+						target:    n.path[i],
+						bandwidth: req.bandwidth,
+						req:       req,
+					})
+
+					hops++
+				}
+
+				success = true
+				version = v
+				distance += n.distance
+				neighborHits++
+
+				C.cache.hit(n.sat, req.item)
+				C.consistency.validate(n.sat, req, shortestSatPaths, gndSatLinks, &records.tx)
+
+				break
+			}
+		}
+
+		// if nobody has it: request to origin server
+		if !success {
+			for i := 1; i < len(req.path)-1; i++ {
+				source := req.path[i]
+				target := req.path[i+1]
+
+				records.tx = append(records.tx, txRecord{
+					source:    source,
+					target:    target,
+					bandwidth: req.bandwidth,
+					req:       req,
+				})
+
+				hops++
+			}
+
+			distance = requestDistance(req, len(req.path)-1, shortestSatPaths, gndSatLinks)
+		}
+
+		records.record(req, success, success && version != req.version, hops, distance)
+
+		// write that item into the cache for the next round
+		C.cache.add(firstSat, req.item, version)
+	}
+
+	C.metrics = append([]metric{
+		{name: "lookup_messages", value: float64(lookupMessages)},
+		{name: "local_hits", value: float64(localHits)},
+		{name: "neighbor_hits", value: float64(neighborHits)},
+	}, C.cache.admissionMetrics()...)
+
+	return records.result(C.cache.getStore())
+
+}
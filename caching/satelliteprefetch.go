@@ -0,0 +1,430 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// satellitePrefetchCache is like satelliteCache, but it watches which satellite serves each ground station
+// shortly before a ground station is handed over to the next satellite, the items that ground station requested
+// most are copied from the current satellite to the satellite that is expected to take over
+type satellitePrefetchCache struct {
+	name          string
+	constellation *constellation
+	cache         *nodeCaches
+	lookup        *satelliteLookup
+	consistency   *cacheConsistency
+	// how many of the hottest items of a ground station are prefetched
+	numItems int64
+	// how many seconds before the expected handover we prefetch
+	lead int64
+
+	gsts map[int64]*prefetchGst
+	// handovers we have seen, per ground station and satellite
+	transitions map[int64]map[int64]int64
+	// observed time a ground station stays with one satellite
+	residenceTotal int64
+	residenceCount int64
+	// items that were prefetched to a satellite and have not been requested there yet
+	prefetched map[int64]map[int64]struct{}
+
+	metrics []metric
+}
+
+type prefetchGst struct {
+	serving int64
+	// when the ground station was handed over to the serving satellite, or when we first saw it
+	since int64
+	// whether we have seen the handover to the serving satellite
+	handedOver bool
+	// satellite we prefetched to, -1 if we have not prefetched for the serving satellite
+	predicted int64
+	// requests per item, halved on every handover so that old requests count less
+	requests map[int64]int64
+}
+
+func init() {
+	registerStrategy("SATELLITE-PREFETCH", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		lookup := getSatelliteLookup(conf)
+		consistency := getCacheConsistency(conf, env)
+
+		numItems := conf.getInt64("prefetch_items", 10)
+		lead := conf.getInt64("prefetch_lead", 10)
+
+		if numItems < 0 || lead < 0 {
+			panic("SATELLITE-PREFETCH: prefetch_items and prefetch_lead must not be negative")
+		}
+
+		return newSatellitePrefetch(conf.getName("SATELLITE-PREFETCH-"+strconv.FormatInt(numItems, 10)+cacheConf.suffix()+lookup.suffix()+consistency.suffix()), env.constellation, cacheConf, lookup, consistency, numItems, lead, env.itemSizes)
+	})
+}
+
+func newSatellitePrefetch(name string, constellation *constellation, cacheConf cacheConfig, lookup *satelliteLookup, consistency *cacheConsistency, numItems int64, lead int64, itemSizes *map[int64]int64) *satellitePrefetchCache {
+	return &satellitePrefetchCache{
+		name:          name,
+		constellation: constellation,
+		cache:         newNodeCaches(cacheConf, itemSizes),
+		lookup:        lookup,
+		consistency:   consistency,
+		numItems:      numItems,
+		lead:          lead,
+		gsts:          make(map[int64]*prefetchGst),
+		transitions:   make(map[int64]map[int64]int64),
+		prefetched:    make(map[int64]map[int64]struct{}),
+	}
+}
+
+func (C *satellitePrefetchCache) getName() string {
+	return C.name
+}
+
+func (C *satellitePrefetchCache) getStoreNodes() int64 {
+	return C.constellation.numSats()
+}
+
+func (C *satellitePrefetchCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	for sat := range nodes {
+		delete(C.prefetched, sat)
+	}
+
+	return C.cache.fail(nodes)
+}
+
+func (C *satellitePrefetchCache) getMetrics() *[]metric {
+	return &C.metrics
+}
+
+// residence is the expected time between two handovers of a ground station
+// until we have seen a full one, we assume it is the time a satellite needs to move to the position of the one in front of it
+func (C *satellitePrefetchCache) residence() int64 {
+	if C.residenceCount == 0 {
+		return C.constellation.intraPlaneInterval()
+	}
+
+	return C.residenceTotal / C.residenceCount
+}
+
+// predict the satellite that takes over a ground station from sat
+// if we have seen that handover before, it will probably happen again, otherwise the next satellite in the plane moves in
+func (C *satellitePrefetchCache) predict(gst int64, sat int64) int64 {
+	if next, ok := C.transitions[gst][sat]; ok {
+		return next
+	}
+
+	return C.constellation.intraPlanePredecessor(sat)
+}
+
+// hotItems are the items a ground station requested most, most requested first
+func (g *prefetchGst) hotItems(n int64) []int64 {
+	items := make([]int64, 0, len(g.requests))
+
+	for item := range g.requests {
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if g.requests[items[i]] != g.requests[items[j]] {
+			return g.requests[items[i]] > g.requests[items[j]]
+		}
+
+		return items[i] < items[j]
+	})
+
+	if int64(len(items)) > n {
+		items = items[:n]
+	}
+
+	return items
+}
+
+// track which satellite serves each ground station and learn from handovers
+func (C *satellitePrefetchCache) track(time int64, gndSatLinks *map[int64]gndSatLink) (int64, int64) {
+	var handovers int64
+	var predicted int64
+
+	for gnd, l := range *gndSatLinks {
+		g, ok := C.gsts[gnd]
+
+		if !ok {
+			C.gsts[gnd] = &prefetchGst{
+				serving:   l.sat,
+				since:     time,
+				predicted: -1,
+				requests:  make(map[int64]int64),
+			}
+			continue
+		}
+
+		if g.serving == l.sat {
+			continue
+		}
+
+		handovers++
+
+		if g.predicted == l.sat {
+			predicted++
+		}
+
+		if _, ok := C.transitions[gnd]; !ok {
+			C.transitions[gnd] = make(map[int64]int64)
+		}
+
+		C.transitions[gnd][g.serving] = l.sat
+
+		if g.handedOver {
+			C.residenceTotal += time - g.since
+			C.residenceCount++
+		}
+
+		g.serving = l.sat
+		g.since = time
+		g.handedOver = true
+		g.predicted = -1
+
+		for item, n := range g.requests {
+			if n/2 == 0 {
+				delete(g.requests, item)
+				continue
+			}
+
+			g.requests[item] = n / 2
+		}
+	}
+
+	return handovers, predicted
+}
+
+// prefetchState is how what the strategy has learned is stored in a checkpoint
+type prefetchState struct {
+	Gsts           map[int64]prefetchGstState
+	Transitions    map[int64]map[int64]int64
+	ResidenceTotal int64
+	ResidenceCount int64
+	Prefetched     map[int64][]int64
+}
+
+type prefetchGstState struct {
+	Serving    int64
+	Since      int64
+	HandedOver bool
+	Predicted  int64
+	Requests   map[int64]int64
+}
+
+func (C *satellitePrefetchCache) save(w *checkpointWriter) {
+	s := prefetchState{
+		Gsts:           make(map[int64]prefetchGstState, len(C.gsts)),
+		Transitions:    C.transitions,
+		ResidenceTotal: C.residenceTotal,
+		ResidenceCount: C.residenceCount,
+		Prefetched:     make(map[int64][]int64, len(C.prefetched)),
+	}
+
+	for gnd, g := range C.gsts {
+		s.Gsts[gnd] = prefetchGstState{
+			Serving:    g.serving,
+			Since:      g.since,
+			HandedOver: g.handedOver,
+			Predicted:  g.predicted,
+			Requests:   g.requests,
+		}
+	}
+
+	for sat, items := range C.prefetched {
+		for item := range items {
+			s.Prefetched[sat] = append(s.Prefetched[sat], item)
+		}
+	}
+
+	w.write(s)
+
+	C.lookup.save(w)
+	C.cache.save(w)
+}
+
+func (C *satellitePrefetchCache) load(r *checkpointReader) {
+	var s prefetchState
+	r.read(&s)
+
+	for gnd, g := range s.Gsts {
+		C.gsts[gnd] = &prefetchGst{
+			serving:    g.Serving,
+			since:      g.Since,
+			handedOver: g.HandedOver,
+			predicted:  g.Predicted,
+			requests:   make(map[int64]int64, len(g.Requests)),
+		}
+
+		for item, n := range g.Requests {
+			C.gsts[gnd].requests[item] = n
+		}
+	}
+
+	for gnd, transitions := range s.Transitions {
+		C.transitions[gnd] = transitions
+	}
+
+	C.residenceTotal = s.ResidenceTotal
+	C.residenceCount = s.ResidenceCount
+
+	for sat, items := range s.Prefetched {
+		C.prefetched[sat] = make(map[int64]struct{}, len(items))
+
+		for _, item := range items {
+			C.prefetched[sat][item] = struct{}{}
+		}
+	}
+
+	C.lookup.load(r)
+	C.cache.load(r)
+}
+
+// prefetch hot items for all ground stations that are about to be handed over
+func (C *satellitePrefetchCache) prefetch(time int64, shortestSatPaths *map[int64]map[int64]satPath, txRecords *[]txRecord) int64 {
+	var prefetchedItems int64
+
+	gnds := make([]int64, 0, len(C.gsts))
+
+	for gnd := range C.gsts {
+		gnds = append(gnds, gnd)
+	}
+
+	sort.Slice(gnds, func(i, j int) bool {
+		return gnds[i] < gnds[j]
+	})
+
+	residence := C.residence()
+
+	for _, gnd := range gnds {
+		g := C.gsts[gnd]
+
+		if g.predicted >= 0 || len(g.requests) == 0 {
+			continue
+		}
+
+		if time-g.since < residence-C.lead {
+			continue
+		}
+
+		next := C.predict(gnd, g.serving)
+		g.predicted = next
+
+		path := findSatPath(C.constellation, shortestSatPaths, g.serving, next)
+
+		for _, item := range g.hotItems(C.numItems) {
+			version, ok := C.cache.version(g.serving, item)
+
+			if !ok || C.cache.has(next, item) {
+				continue
+			}
+
+			for i := 0; i < len(path)-1; i++ {
+				*txRecords = append(*txRecords, txRecord{
+					source:    path[i],
+					target:    path[i+1],
+					bandwidth: C.cache.itemSizes[item],
+					kind:      txPrefetch,
+				})
+			}
+
+			for _, evicted := range C.cache.add(next, item, version) {
+				delete(C.prefetched[next], evicted)
+			}
+
+			// the item was sent anyway, but the satellite did not admit it
+			if !C.cache.has(next, item) {
+				continue
+			}
+
+			if _, ok := C.prefetched[next]; !ok {
+				C.prefetched[next] = make(map[int64]struct{})
+			}
+
+			C.prefetched[next][item] = struct{}{}
+			prefetchedItems++
+		}
+	}
+
+	return prefetchedItems
+}
+
+func (C *satellitePrefetchCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	handovers, predicted := C.track(time, gndSatLinks)
+
+	var prefetchHits int64
+
+	// outdated copies are removed before anything is looked up, a prefetched copy that is removed was never requested
+	for _, r := range C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx) {
+		delete(C.prefetched[r[0]], r[1])
+	}
+
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
+
+	for _, req := range *requests {
+		// find the satellite that has the item in cache, or go all the way to the origin
+		servedBy := C.lookup.find(req, scache, C.consistency)
+		success := servedBy < len(req.path)-1
+		stale := success && scache[req.path[servedBy]][req.item] != req.version
+
+		records.served(req, servedBy, stale)
+
+		// satellites we passed do not have the item anymore, even if we prefetched it there
+		for i := 1; i < servedBy; i++ {
+			delete(C.prefetched[req.path[i]], req.item)
+		}
+
+		if success {
+			C.cache.hit(req.path[servedBy], req.item)
+			C.consistency.validate(req.path[servedBy], req, shortestSatPaths, gndSatLinks, &records.tx)
+
+			if _, ok := C.prefetched[req.path[servedBy]][req.item]; ok {
+				prefetchHits++
+				delete(C.prefetched[req.path[servedBy]], req.item)
+			}
+		}
+
+		if g, ok := C.gsts[req.path[0]]; ok {
+			g.requests[req.item]++
+		}
+
+		// write that item into the caches for the next round
+		// an evicted item that comes back later was not prefetched
+		for _, e := range C.lookup.insert(req, servedBy, C.cache) {
+			delete(C.prefetched[e[0]], e[1])
+		}
+	}
+
+	prefetchedItems := C.prefetch(time, shortestSatPaths, &records.tx)
+
+	C.metrics = append([]metric{
+		{name: "handovers", value: float64(handovers)},
+		{name: "predicted_handovers", value: float64(predicted)},
+		{name: "prefetched_items", value: float64(prefetchedItems)},
+		{name: "prefetch_hits", value: float64(prefetchHits)},
+	}, C.cache.admissionMetrics()...)
+
+	return records.result(C.cache.getStore())
+
+}
@@ -0,0 +1,52 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import "testing"
+
+// a prefetched copy that is invalidated before it is requested must not count as a prefetch hit later
+func TestPrefetchedItemInvalidated(t *testing.T) {
+	sizes := map[int64]int64{1: 100}
+	// item 1 changes every 10 seconds
+	intervals := map[int64]int64{1: 10}
+
+	consistency := &cacheConsistency{
+		mode:     consistencyInvalidate,
+		versions: newItemVersions(&intervals),
+		origins:  map[int64]int64{1: -1},
+	}
+
+	C := newSatellitePrefetch("SATELLITE-PREFETCH", &constellation{planes: 2, satsPerPlane: 2}, cacheConfig{policy: "LRU"}, &satelliteLookup{mode: lookupFirst, insertion: insertionLCE}, consistency, 10, 10, &sizes)
+
+	C.cache.add(0, 1, 0)
+	C.prefetched[0] = map[int64]struct{}{1: {}}
+
+	shortestSatPaths := map[int64]map[int64]satPath{}
+	gndSatLinks := map[int64]gndSatLink{-1: {sat: 0}}
+	requests := []*request{}
+
+	C.stepTo(10, &shortestSatPaths, &gndSatLinks, &requests)
+
+	if C.cache.has(0, 1) {
+		t.Fatal("outdated copy was not invalidated")
+	}
+
+	if _, ok := C.prefetched[0][1]; ok {
+		t.Error("invalidated copy is still marked as prefetched")
+	}
+}
@@ -17,137 +17,123 @@
 
 package main
 
+import "strconv"
+
 type satelliteTimeoutCache struct {
-	lastUpdate   int64
-	satsPerPlane int64
-	numPlanes    int64
-	cache        map[int64]map[int64]struct{}
+	name          string
+	lastUpdate    int64
+	interval      int64
+	constellation *constellation
+	cache         *nodeCaches
+	lookup        *satelliteLookup
+	consistency   *cacheConsistency
+
+	metrics []metric
+}
+
+func init() {
+	registerStrategy("SATELLITE-TIMEOUT", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		lookup := getSatelliteLookup(conf)
+		consistency := getCacheConsistency(conf, env)
+
+		// by default, caches are cleared whenever a satellite has moved to the position of the one in front of it
+		interval := conf.getInt64("interval", env.constellation.intraPlaneInterval())
+
+		if interval <= 0 {
+			panic("SATELLITE-TIMEOUT: interval must be positive")
+		}
+
+		timing := ""
+		if conf.has("interval") {
+			timing = "-" + strconv.FormatInt(interval, 10) + "S"
+		}
 
-	itemSizes map[int64]int64
+		return newSatelliteTimeout(conf.getName("SATELLITE-TIMEOUT"+timing+cacheConf.suffix()+lookup.suffix()+consistency.suffix()), env.constellation, interval, newNodeCaches(cacheConf, env.itemSizes), lookup, consistency)
+	})
 }
 
-func newSatelliteTimeout(itemSizes *map[int64]int64) *satelliteTimeoutCache {
+func newSatelliteTimeout(name string, constellation *constellation, interval int64, cache *nodeCaches, lookup *satelliteLookup, consistency *cacheConsistency) *satelliteTimeoutCache {
 	return &satelliteTimeoutCache{
-		satsPerPlane: 66,
-		numPlanes:    24,
-		cache:        make(map[int64]map[int64]struct{}),
-		itemSizes:    *itemSizes,
+		name:          name,
+		interval:      interval,
+		constellation: constellation,
+		cache:         cache,
+		lookup:        lookup,
+		consistency:   consistency,
 	}
 }
 
+func (C *satelliteTimeoutCache) getMetrics() *[]metric {
+	// without admission control, there is nothing to report
+	if C.metrics == nil {
+		return nil
+	}
+
+	return &C.metrics
+}
+
 func (C *satelliteTimeoutCache) getName() string {
-	return "SATELLITE-TIMEOUT"
+	return C.name
 }
 
 func (C *satelliteTimeoutCache) getStoreNodes() int64 {
-	return 66 * 24
+	return C.constellation.numSats()
 }
 
-func (C *satelliteTimeoutCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord) {
+func (C *satelliteTimeoutCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
 
-	txRecords := []txRecord{}
-	storeRecords := []storeRecord{}
-	cacheRecords := []cacheRecord{}
-	hopsRecords := []hopsRecord{}
+func (C *satelliteTimeoutCache) save(w *checkpointWriter) {
+	w.write(C.lastUpdate)
+	C.lookup.save(w)
+	C.cache.save(w)
+}
 
-	// every 87 seconds: invalidate everything
-	// 5730s / 66 = 86.8
+func (C *satelliteTimeoutCache) load(r *checkpointReader) {
+	r.read(&C.lastUpdate)
+	C.lookup.load(r)
+	C.cache.load(r)
+}
 
-	if time-C.lastUpdate >= 87 {
-		C.lastUpdate = time
-		C.cache = make(map[int64]map[int64]struct{})
-	}
+func (C *satelliteTimeoutCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
 
-	// prepare a copied cache so we can modify the real cache
-	scache := copyCache(C.cache)
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
 
-	for _, req := range *requests {
-		// hops := int64(0)
-		// success := false
-
-		// for i := 0; i < len(req.path)-1; i++ {
-
-		// 	source := req.path[i]
-		// 	target := req.path[i+1]
-
-		// 	if _, ok := scache[source]; ok {
-		// 		if _, ok := scache[source][req.item]; ok {
-		// 			success = true
-		// 			break
-		// 		}
-		// 	}
-
-		// 	txRecords = append(txRecords, txRecord{
-		// 		source:    source,
-		// 		target:    target,
-		// 		bandwidth: req.bandwidth,
-		// 	})
-
-		// 	hops++
-		// }
-
-		hops := int64(0)
-		success := false
-
-		// check if the satellite that got  the request first has the item in cache
-		firstSat := req.path[1]
-		if _, ok := scache[firstSat]; ok {
-			if _, ok := scache[firstSat][req.item]; ok {
-				txRecords = append(txRecords, txRecord{
-					source:    req.path[0],
-					target:    firstSat,
-					bandwidth: req.bandwidth,
-				})
-
-				hops = 1
-
-				success = true
-			}
-		}
+	// every time a satellite has moved to the position of the one in front of it: invalidate everything
+	// unless the interval is set in the config, see intraPlaneInterval
 
-		// if it didn't: request to origin server
-		if !success {
-			for i := 0; i < len(req.path)-1; i++ {
-				source := req.path[i]
-				target := req.path[i+1]
+	if time-C.lastUpdate >= C.interval {
+		C.lastUpdate = time
+		C.cache.clear()
+	}
 
-				txRecords = append(txRecords, txRecord{
-					source:    source,
-					target:    target,
-					bandwidth: req.bandwidth,
-				})
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx)
 
-				hops++
-			}
-		}
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
 
-		cacheRecords = append(cacheRecords, cacheRecord{
-			item:    req.item,
-			success: success,
-		})
+	for _, req := range *requests {
+		// find the satellite that has the item in cache, or go all the way to the origin
+		servedBy := C.lookup.find(req, scache, C.consistency)
+		success := servedBy < len(req.path)-1
+		stale := success && scache[req.path[servedBy]][req.item] != req.version
 
-		hopsRecords = append(hopsRecords, hopsRecord{
-			item: req.item,
-			hops: hops,
-		})
+		records.served(req, servedBy, stale)
 
-		// write that item into the cache for the next round
-		if _, ok := C.cache[firstSat]; !ok {
-			C.cache[firstSat] = make(map[int64]struct{})
+		if success {
+			C.cache.hit(req.path[servedBy], req.item)
+			C.consistency.validate(req.path[servedBy], req, shortestSatPaths, gndSatLinks, &records.tx)
 		}
 
-		C.cache[firstSat][req.item] = struct{}{}
+		// write that item into the caches for the next round
+		C.lookup.insert(req, servedBy, C.cache)
 	}
 
-	for node := range C.cache {
-		for item := range C.cache[node] {
-			storeRecords = append(storeRecords, storeRecord{
-				node: node,
-				item: item,
-			})
-		}
-	}
+	C.metrics = C.cache.admissionMetrics()
 
-	return &txRecords, &storeRecords, &cacheRecords, &hopsRecords
+	return records.result(C.cache.getStore())
 
 }
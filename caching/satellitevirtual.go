@@ -17,115 +17,148 @@
 
 package main
 
+import "strconv"
+
 type satelliteVirtualCache struct {
-	lastIntra    int64
-	lastCross    int64
-	satsPerPlane int64
-	numPlanes    int64
-	cache        map[int64]map[int64]struct{}
-	itemSizes    map[int64]int64
+	name          string
+	lastIntra     int64
+	lastCross     int64
+	intraInterval int64
+	crossInterval int64
+	constellation *constellation
+	cache         *nodeCaches
+	lookup        *satelliteLookup
+	consistency   *cacheConsistency
+	itemSizes     map[int64]int64
+
+	metrics []metric
 }
 
-func newSatelliteVirtual(itemSizes *map[int64]int64) *satelliteVirtualCache {
+func init() {
+	registerStrategy("SATELLITE-VIRTUAL", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		lookup := getSatelliteLookup(conf)
+		consistency := getCacheConsistency(conf, env)
+
+		// by default, caches follow the movement of the satellites and the rotation of earth
+		intraInterval := conf.getInt64("intra_interval", env.constellation.intraPlaneInterval())
+		crossInterval := conf.getInt64("cross_interval", env.constellation.crossPlaneInterval())
+
+		if intraInterval <= 0 || crossInterval <= 0 {
+			panic("SATELLITE-VIRTUAL: intra_interval and cross_interval must be positive")
+		}
+
+		timing := ""
+		if conf.has("intra_interval") || conf.has("cross_interval") {
+			timing = "-" + strconv.FormatInt(intraInterval, 10) + "S-" + strconv.FormatInt(crossInterval, 10) + "S"
+		}
+
+		return newSatelliteVirtual(conf.getName("SATELLITE-VIRTUAL"+timing+cacheConf.suffix()+lookup.suffix()+consistency.suffix()), env.constellation, intraInterval, crossInterval, cacheConf, lookup, consistency, env.itemSizes)
+	})
+}
+
+func newSatelliteVirtual(name string, constellation *constellation, intraInterval int64, crossInterval int64, cacheConf cacheConfig, lookup *satelliteLookup, consistency *cacheConsistency, itemSizes *map[int64]int64) *satelliteVirtualCache {
 	return &satelliteVirtualCache{
-		satsPerPlane: 66,
-		numPlanes:    24,
-		cache:        make(map[int64]map[int64]struct{}),
-		itemSizes:    *itemSizes,
+		name:          name,
+		intraInterval: intraInterval,
+		crossInterval: crossInterval,
+		constellation: constellation,
+		cache:         newNodeCaches(cacheConf, itemSizes),
+		lookup:        lookup,
+		consistency:   consistency,
+		itemSizes:     *itemSizes,
 	}
 }
 
+func (C *satelliteVirtualCache) getMetrics() *[]metric {
+	// without admission control, there is nothing to report
+	if C.metrics == nil {
+		return nil
+	}
+
+	return &C.metrics
+}
+
 func (C *satelliteVirtualCache) getName() string {
-	return "SATELLITE-VIRTUAL"
+	return C.name
 }
 
 func (C *satelliteVirtualCache) getStoreNodes() int64 {
-	return 66 * 24
+	return C.constellation.numSats()
 }
 
-func (C *satelliteVirtualCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord) {
+func (C *satelliteVirtualCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
+
+func (C *satelliteVirtualCache) save(w *checkpointWriter) {
+	w.write([]int64{C.lastIntra, C.lastCross})
+	C.lookup.save(w)
+	C.cache.save(w)
+}
 
-	txRecords := []txRecord{}
-	// at least as much as satellites already caching stuff
-	storeRecords := make([]storeRecord, 0, len(C.cache))
-	// we always need as many cache records as we have requests
-	cacheRecords := make([]cacheRecord, 0, len(*requests))
-	// same goes for hops records
-	hopsRecords := make([]hopsRecord, 0, len(*requests))
+func (C *satelliteVirtualCache) load(r *checkpointReader) {
+	var last []int64
+	r.read(&last)
+	C.lastIntra, C.lastCross = last[0], last[1]
 
-	// every 86 seconds: intra-plane backward propagation
-	if time-C.lastIntra >= 87 {
-		newCache := make(map[int64]map[int64]struct{})
+	C.lookup.load(r)
+	C.cache.load(r)
+}
 
-		for sat, cache := range C.cache {
-			posInPlane := sat % C.satsPerPlane
-			thisPlane := (sat - posInPlane) / C.satsPerPlane
+func (C *satelliteVirtualCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
 
-			nextSatPosInPlane := posInPlane - 1
-			if nextSatPosInPlane <= 0 {
-				nextSatPosInPlane = C.satsPerPlane
-			}
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
 
+	// every intra-plane interval (87 seconds for the first starlink shell): intra-plane backward propagation
+	if time-C.lastIntra >= C.intraInterval {
+		for sat, cache := range C.cache.nodes {
 			// it's possible that we haven't actually calculated the path between these nodes
 			// but that's ok!
 			// we only have one intra-plane link for intra-plane backward propagation
 
 			// propagate one sat back
-			satToPropagateTo := (thisPlane * C.satsPerPlane) + nextSatPosInPlane
+			satToPropagateTo := C.constellation.intraPlanePredecessor(sat)
 			path := []int64{sat, satToPropagateTo}
 
 			if sat > satToPropagateTo {
 				path = []int64{satToPropagateTo, sat}
 			}
 
-			newCache[satToPropagateTo] = make(map[int64]struct{})
-
-			for item := range cache {
-				newCache[satToPropagateTo][item] = struct{}{}
-
-				if _, ok := C.cache[satToPropagateTo]; ok {
-					if _, ok := C.cache[satToPropagateTo][item]; ok {
-						// item is in cache already, more efficient
-						continue
-					}
+			for item := range cache.items {
+				if C.cache.has(satToPropagateTo, item) {
+					// item is in cache already, more efficient
+					continue
 				}
 
 				source := path[0]
 				target := path[1]
 
-				txRecords = append(txRecords, txRecord{
+				records.tx = append(records.tx, txRecord{
 					source:    source,
 					target:    target,
 					bandwidth: C.itemSizes[item],
+					kind:      txMigration,
 				})
 
 			}
 		}
 
 		C.lastIntra = time
-		C.cache = newCache
+		C.cache.move(C.constellation.intraPlanePredecessor)
 	}
 
-	// every 3600 seconds: cross-plane forward propagation
-	// the first timestamp where both cross- and intra-plane propagation will occur at the same time is 104400s, which is ok for our simulation
+	// every cross-plane interval (3600 seconds for 24 planes): cross-plane forward propagation
+	// for the first starlink shell, the first timestamp where both cross- and intra-plane propagation will occur at the same time is 104400s, which is ok for our simulation
 	// in theory, if both occur at the same time, there would be no need to first to intra- and then cross-plane, instead both could be merged into one
-	if time-C.lastCross >= 3600 {
-		newCache := make(map[int64]map[int64]struct{})
-
-		for sat, cache := range C.cache {
-			posInPlane := sat % C.satsPerPlane
-			thisPlane := (sat - posInPlane) / C.satsPerPlane
-
-			nextPlane := thisPlane + 1
-			if nextPlane >= C.numPlanes {
-				nextPlane = 0
-			}
+	if time-C.lastCross >= C.crossInterval {
+		for sat, cache := range C.cache.nodes {
 			// it's possible that we haven't actually calculated the path between these nodes
 			// but that's ok!
 			// we only have one for cross-plane propagation
 
 			// if that's not the one we want, add another cross-plane hops
-			satToPropagateTo := (nextPlane * C.satsPerPlane) + posInPlane
+			satToPropagateTo := C.constellation.crossPlaneNeighbor(sat)
 
 			path := []int64{sat, satToPropagateTo}
 
@@ -133,124 +166,54 @@ func (C *satelliteVirtualCache) stepTo(time int64, shortestSatPaths *map[int64]m
 				path = []int64{satToPropagateTo, sat}
 			}
 
-			newCache[satToPropagateTo] = make(map[int64]struct{})
-
-			for item := range cache {
-				newCache[satToPropagateTo][item] = struct{}{}
-
-				if _, ok := C.cache[satToPropagateTo]; ok {
-					if _, ok := C.cache[satToPropagateTo][item]; ok {
-						// item is in cache already, more efficient
-						continue
-					}
+			for item := range cache.items {
+				if C.cache.has(satToPropagateTo, item) {
+					// item is in cache already, more efficient
+					continue
 				}
 
 				source := path[0]
 				target := path[1]
 
-				txRecords = append(txRecords, txRecord{
+				records.tx = append(records.tx, txRecord{
 					source:    source,
 					target:    target,
 					bandwidth: C.itemSizes[item],
+					kind:      txMigration,
 				})
 
 			}
 		}
 
 		C.lastCross = time
-		C.cache = newCache
+		C.cache.move(C.constellation.crossPlaneNeighbor)
 	}
 
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx)
+
 	// prepare a copied cache so we can modify the real cache
-	scache := copyCache(C.cache)
+	scache := C.cache.snapshot()
 
 	for _, req := range *requests {
-		// hops := int64(0)
-		// success := false
-
-		// for i := 0; i < len(req.path)-1; i++ {
-
-		// 	source := req.path[i]
-		// 	target := req.path[i+1]
-
-		// 	if _, ok := scache[source]; ok {
-		// 		if _, ok := scache[source][req.item]; ok {
-		// 			success = true
-		// 			break
-		// 		}
-		// 	}
-
-		// 	txRecords = append(txRecords, txRecord{
-		// 		source:    source,
-		// 		target:    target,
-		// 		bandwidth: req.bandwidth,
-		// 	})
-
-		// 	hops++
-		// }
-
-		hops := int64(0)
-		success := false
-
-		// check if the satellite that got  the request first has the item in cache
-		firstSat := req.path[1]
-		if _, ok := scache[firstSat]; ok {
-			if _, ok := scache[firstSat][req.item]; ok {
-				txRecords = append(txRecords, txRecord{
-					source:    req.path[0],
-					target:    firstSat,
-					bandwidth: req.bandwidth,
-				})
+		// find the satellite that has the item in cache, or go all the way to the origin
+		servedBy := C.lookup.find(req, scache, C.consistency)
+		success := servedBy < len(req.path)-1
+		stale := success && scache[req.path[servedBy]][req.item] != req.version
 
-				hops = 1
+		records.served(req, servedBy, stale)
 
-				success = true
-			}
+		if success {
+			C.cache.hit(req.path[servedBy], req.item)
+			C.consistency.validate(req.path[servedBy], req, shortestSatPaths, gndSatLinks, &records.tx)
 		}
 
-		// if it didn't: request to origin server
-		if !success {
-			for i := 0; i < len(req.path)-1; i++ {
-				source := req.path[i]
-				target := req.path[i+1]
-
-				txRecords = append(txRecords, txRecord{
-					source:    source,
-					target:    target,
-					bandwidth: req.bandwidth,
-				})
-
-				hops++
-			}
-		}
-
-		cacheRecords = append(cacheRecords, cacheRecord{
-			item:    req.item,
-			success: success,
-		})
-
-		hopsRecords = append(hopsRecords, hopsRecord{
-			item: req.item,
-			hops: hops,
-		})
-
-		// write that item into the cache for the next round
-		if _, ok := C.cache[firstSat]; !ok {
-			C.cache[firstSat] = make(map[int64]struct{})
-		}
-
-		C.cache[firstSat][req.item] = struct{}{}
+		// write that item into the caches for the next round
+		C.lookup.insert(req, servedBy, C.cache)
 	}
 
-	for node := range C.cache {
-		for item := range C.cache[node] {
-			storeRecords = append(storeRecords, storeRecord{
-				node: node,
-				item: item,
-			})
-		}
-	}
+	C.metrics = C.cache.admissionMetrics()
 
-	return &txRecords, &storeRecords, &cacheRecords, &hopsRecords
+	return records.result(C.cache.getStore())
 
 }
@@ -0,0 +1,218 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"sort"
+)
+
+// satelliteVirtualTopologyCache is like satelliteVirtualCache, but instead of moving caches by satellite IDs at fixed intervals,
+// it follows the ground stations: whenever a ground station is handed over, the cache of the satellite that served it
+// moves to the satellite that now serves most of the ground stations it served before
+// items are sent over the shortest ISL path between the two satellites
+type satelliteVirtualTopologyCache struct {
+	name          string
+	constellation *constellation
+	cache         *nodeCaches
+	lookup        *satelliteLookup
+	consistency   *cacheConsistency
+	itemSizes     map[int64]int64
+
+	// satellite that served each ground node in the last step
+	serving map[int64]int64
+
+	metrics []metric
+}
+
+func init() {
+	registerStrategy("SATELLITE-VIRTUAL-TOPOLOGY", func(conf *strategyConfig, env *strategyEnv) strategy {
+		cacheConf := getCacheConfig(conf)
+		lookup := getSatelliteLookup(conf)
+		consistency := getCacheConsistency(conf, env)
+
+		return newSatelliteVirtualTopology(conf.getName("SATELLITE-VIRTUAL-TOPOLOGY"+cacheConf.suffix()+lookup.suffix()+consistency.suffix()), env.constellation, cacheConf, lookup, consistency, env.itemSizes)
+	})
+}
+
+func newSatelliteVirtualTopology(name string, constellation *constellation, cacheConf cacheConfig, lookup *satelliteLookup, consistency *cacheConsistency, itemSizes *map[int64]int64) *satelliteVirtualTopologyCache {
+	return &satelliteVirtualTopologyCache{
+		name:          name,
+		constellation: constellation,
+		cache:         newNodeCaches(cacheConf, itemSizes),
+		lookup:        lookup,
+		consistency:   consistency,
+		itemSizes:     *itemSizes,
+		serving:       make(map[int64]int64),
+	}
+}
+
+func (C *satelliteVirtualTopologyCache) getMetrics() *[]metric {
+	return &C.metrics
+}
+
+func (C *satelliteVirtualTopologyCache) getName() string {
+	return C.name
+}
+
+func (C *satelliteVirtualTopologyCache) getStoreNodes() int64 {
+	return C.constellation.numSats()
+}
+
+func (C *satelliteVirtualTopologyCache) fail(nodes map[int64]struct{}) (int64, int64) {
+	return C.cache.fail(nodes)
+}
+
+func (C *satelliteVirtualTopologyCache) save(w *checkpointWriter) {
+	w.write(C.serving)
+	C.lookup.save(w)
+	C.cache.save(w)
+}
+
+func (C *satelliteVirtualTopologyCache) load(r *checkpointReader) {
+	var serving map[int64]int64
+	r.read(&serving)
+
+	for gnd, sat := range serving {
+		C.serving[gnd] = sat
+	}
+
+	C.lookup.load(r)
+	C.cache.load(r)
+}
+
+// targets finds the satellite each cache should move to and remembers which satellite serves each ground node
+// a satellite that still serves most of its ground nodes keeps its cache, as does one that did not serve any
+// it returns the number of handovers and the moves
+func (C *satelliteVirtualTopologyCache) targets(gndSatLinks *map[int64]gndSatLink) (int64, map[int64]int64) {
+	var handovers int64
+
+	// for each satellite, how many of the ground nodes it served are now served by which satellite
+	successors := make(map[int64]map[int64]int64)
+
+	for gnd, l := range *gndSatLinks {
+		old, ok := C.serving[gnd]
+		C.serving[gnd] = l.sat
+
+		if !ok {
+			continue
+		}
+
+		if old != l.sat {
+			handovers++
+		}
+
+		if _, ok := successors[old]; !ok {
+			successors[old] = make(map[int64]int64)
+		}
+
+		successors[old][l.sat]++
+	}
+
+	moves := make(map[int64]int64)
+
+	for old, s := range successors {
+		// ties go to the satellite with the lower ID so that runs are reproducible
+		sats := make([]int64, 0, len(s))
+
+		for sat := range s {
+			sats = append(sats, sat)
+		}
+
+		sort.Slice(sats, func(i, j int) bool {
+			if s[sats[i]] != s[sats[j]] {
+				return s[sats[i]] > s[sats[j]]
+			}
+
+			return sats[i] < sats[j]
+		})
+
+		if sats[0] != old {
+			moves[old] = sats[0]
+		}
+	}
+
+	return handovers, moves
+}
+
+// migrate moves the caches and records the traffic on every ISL on the way
+// it returns the number of items that were sent and the ISL hops they took in total
+func (C *satelliteVirtualTopologyCache) migrate(moves map[int64]int64, shortestSatPaths *map[int64]map[int64]satPath, txRecords *[]txRecord) (int64, int64) {
+	var migrated int64
+	var hops int64
+
+	for sat, items := range C.cache.merge(moves) {
+		path := findSatPath(C.constellation, shortestSatPaths, sat, moves[sat])
+
+		for _, item := range items {
+			for i := 0; i < len(path)-1; i++ {
+				*txRecords = append(*txRecords, txRecord{
+					source:    path[i],
+					target:    path[i+1],
+					bandwidth: C.itemSizes[item],
+					kind:      txMigration,
+				})
+			}
+		}
+
+		migrated += int64(len(items))
+		hops += int64(len(items) * (len(path) - 1))
+	}
+
+	return migrated, hops
+}
+
+func (C *satelliteVirtualTopologyCache) stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+
+	records := newStepRecords(shortestSatPaths, gndSatLinks, requests)
+
+	// caches move before anything is looked up, so requests already find them at the satellite that serves their ground station
+	handovers, moves := C.targets(gndSatLinks)
+	migrated, migrationHops := C.migrate(moves, shortestSatPaths, &records.tx)
+
+	// outdated copies are removed before anything is looked up
+	C.consistency.invalidate(time, C.cache, nil, shortestSatPaths, gndSatLinks, &records.tx)
+
+	// prepare a copied cache so we can modify the real cache
+	scache := C.cache.snapshot()
+
+	for _, req := range *requests {
+		// find the satellite that has the item in cache, or go all the way to the origin
+		servedBy := C.lookup.find(req, scache, C.consistency)
+		success := servedBy < len(req.path)-1
+		stale := success && scache[req.path[servedBy]][req.item] != req.version
+
+		records.served(req, servedBy, stale)
+
+		if success {
+			C.cache.hit(req.path[servedBy], req.item)
+			C.consistency.validate(req.path[servedBy], req, shortestSatPaths, gndSatLinks, &records.tx)
+		}
+
+		// write that item into the caches for the next round
+		C.lookup.insert(req, servedBy, C.cache)
+	}
+
+	C.metrics = append([]metric{
+		{name: "handovers", value: float64(handovers)},
+		{name: "migrated_items", value: float64(migrated)},
+		{name: "migration_hops", value: float64(migrationHops)},
+	}, C.cache.admissionMetrics()...)
+
+	return records.result(C.cache.getStore())
+
+}
@@ -67,7 +67,7 @@ func getShortestSatPaths(sspFile string) *map[int64]map[int64]satPath {
 		}
 
 		// fourth item: path delimited by "|"
-		path := strings.Split(line[2], "|")
+		path := strings.Split(line[3], "|")
 
 		if _, ok := shortestSatPaths[source]; !ok {
 			shortestSatPaths[source] = make(map[int64]satPath)
@@ -81,3 +81,87 @@ func getShortestSatPaths(sspFile string) *map[int64]map[int64]satPath {
 
 	return &shortestSatPaths
 }
+
+// getSatPath returns the path from one satellite to another
+// paths are only stored once for each pair of satellites, from the lower to the higher id
+func getSatPath(shortestSatPaths *map[int64]map[int64]satPath, from int64, to int64) (satPath, bool) {
+	if from == to {
+		return satPath{
+			path:     &[]int64{from},
+			distance: 0,
+		}, true
+	}
+
+	if from < to {
+		p, ok := (*shortestSatPaths)[from][to]
+		return p, ok
+	}
+
+	p, ok := (*shortestSatPaths)[to][from]
+
+	if !ok {
+		return p, false
+	}
+
+	reversed := make([]int64, len(*p.path))
+
+	for i, sat := range *p.path {
+		reversed[len(reversed)-1-i] = sat
+	}
+
+	return satPath{
+		path:     &reversed,
+		distance: p.distance,
+	}, true
+}
+
+// findSatPath returns the path from one satellite to another
+// we only know the shortest paths between satellites that serve ground stations, for all others we take a shortest path over the +Grid links
+func findSatPath(C *constellation, shortestSatPaths *map[int64]map[int64]satPath, from int64, to int64) []int64 {
+	if p, ok := getSatPath(shortestSatPaths, from, to); ok {
+		return *p.path
+	}
+
+	if path, ok := C.gridPath(from, to); ok {
+		return path
+	}
+
+	panic("no path between satellites " + strconv.FormatInt(from, 10) + " and " + strconv.FormatInt(to, 10))
+}
+
+// getPath finds the path between two nodes, ground nodes use the link to their satellite
+func getPath(C *constellation, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, from int64, to int64) []int64 {
+	path := []int64{}
+
+	first := from
+	last := to
+
+	if from < 0 {
+		l, ok := (*gndSatLinks)[from]
+
+		if !ok {
+			panic("no satellite link for ground node " + strconv.FormatInt(from, 10))
+		}
+
+		path = append(path, from)
+		first = l.sat
+	}
+
+	if to < 0 {
+		l, ok := (*gndSatLinks)[to]
+
+		if !ok {
+			panic("no satellite link for ground node " + strconv.FormatInt(to, 10))
+		}
+
+		last = l.sat
+	}
+
+	path = append(path, findSatPath(C, shortestSatPaths, first, last)...)
+
+	if to < 0 {
+		path = append(path, to)
+	}
+
+	return path
+}
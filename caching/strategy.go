@@ -20,5 +20,155 @@ package main
 type strategy interface {
 	getName() string
 	getStoreNodes() int64
-	stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord)
+	stepTo(time int64, shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord)
+	// save writes everything the strategy has learned so far to a checkpoint
+	// load restores it into a new strategy with the same configuration, which then continues as if it had never stopped
+	save(w *checkpointWriter)
+	load(r *checkpointReader)
+	// fail empties the caches of the nodes that are down in the next step and keeps them from caching anything in it
+	// it returns how many items and bytes were lost
+	fail(nodes map[int64]struct{}) (int64, int64)
+}
+
+// incrementalStore is implemented by strategies that only return store records for the items
+// they added in a step, e.g., because their caches never remove anything
+type incrementalStore interface {
+	storeIsIncremental() bool
+}
+
+// metricsReporter is implemented by strategies that measure more than the common records
+type metricsReporter interface {
+	// getMetrics returns the metrics of the last step, always in the same order
+	getMetrics() *[]metric
+}
+
+// strategyEnv holds everything a strategy might need from the workload
+// besides its own parameters
+type strategyEnv struct {
+	itemSizes     *map[int64]int64
+	itemVersions  *itemVersions
+	cityFile      string
+	loadFile      string
+	locationFile  string
+	constellation *constellation
+	// where the requests of every step can be found, only for strategies that need to know the future
+	resultFiles string
+	steps       int64
+	stepLength  int64
+	numRequests int
+	// what the oracles know about the requests of the run, made by the first one that needs it
+	optimalPlan *optimalPlan
+}
+
+// stepRecords collects what a strategy records in one step
+// traffic that is not caused by serving a request, e.g., invalidations, is appended to tx directly
+type stepRecords struct {
+	shortestSatPaths *map[int64]map[int64]satPath
+	gndSatLinks      *map[int64]gndSatLink
+
+	tx      []txRecord
+	cache   []cacheRecord
+	hops    []hopsRecord
+	latency []latencyRecord
+}
+
+func newStepRecords(shortestSatPaths *map[int64]map[int64]satPath, gndSatLinks *map[int64]gndSatLink, requests *[]*request) *stepRecords {
+	// every request gets exactly one cache, hops, and latency record
+	return &stepRecords{
+		shortestSatPaths: shortestSatPaths,
+		gndSatLinks:      gndSatLinks,
+		tx:               []txRecord{},
+		cache:            make([]cacheRecord, 0, len(*requests)),
+		hops:             make([]hopsRecord, 0, len(*requests)),
+		latency:          make([]latencyRecord, 0, len(*requests)),
+	}
+}
+
+// served records a request that was served by the node at index servedBy of its path
+// the request travels along its path up to that node, which is a cache unless it is the origin
+func (R *stepRecords) served(req *request, servedBy int, stale bool) {
+	for i := 0; i < servedBy; i++ {
+		R.tx = append(R.tx, txRecord{
+			source:    req.path[i],
+			target:    req.path[i+1],
+			bandwidth: req.bandwidth,
+			req:       req,
+		})
+	}
+
+	R.record(req, servedBy < len(req.path)-1, stale, int64(servedBy), requestDistance(req, servedBy, R.shortestSatPaths, R.gndSatLinks))
+}
+
+// record records the outcome of a request whose traffic the strategy has recorded itself
+func (R *stepRecords) record(req *request, success bool, stale bool, hops int64, distance int64) {
+	R.cache = append(R.cache, cacheRecord{
+		item:    req.item,
+		gst:     req.path[0],
+		bytes:   req.bandwidth,
+		origin:  req.path[len(req.path)-1],
+		success: success,
+		stale:   stale,
+		failed:  req.failed,
+	})
+
+	R.hops = append(R.hops, hopsRecord{
+		item: req.item,
+		gst:  req.path[0],
+		hops: hops,
+	})
+
+	R.latency = append(R.latency, latencyRecord{
+		item:     req.item,
+		gst:      req.path[0],
+		distance: distance,
+		hops:     hops,
+	})
+}
+
+// result returns the records together with the store records of the step, as stepTo does
+func (R *stepRecords) result(storeRecords *[]storeRecord) (*[]txRecord, *[]storeRecord, *[]cacheRecord, *[]hopsRecord, *[]latencyRecord) {
+	return &R.tx, storeRecords, &R.cache, &R.hops, &R.latency
+}
+
+// strategyFactory creates a new strategy from a [[strategy]] entry in the workload config
+type strategyFactory func(conf *strategyConfig, env *strategyEnv) strategy
+
+var strategyFactories = make(map[string]strategyFactory)
+
+// registerStrategy makes a strategy type available for use in the workload config
+// it should be called from an init function in the file that implements the strategy
+func registerStrategy(kind string, factory strategyFactory) {
+	if _, ok := strategyFactories[kind]; ok {
+		panic("caching strategy registered twice: " + kind)
+	}
+
+	strategyFactories[kind] = factory
+}
+
+// newStrategies creates one strategy per config entry, in the order they are given
+func newStrategies(confs []*strategyConfig, env *strategyEnv) []strategy {
+	C := make([]strategy, 0, len(confs))
+
+	names := make(map[string]struct{})
+
+	for _, conf := range confs {
+		factory, ok := strategyFactories[conf.kind]
+
+		if !ok {
+			panic("Unknown caching strategy: " + conf.kind)
+		}
+
+		c := factory(conf, env)
+
+		// every strategy writes to its own set of files, so names must not collide
+		if _, ok := names[c.getName()]; ok {
+			panic("duplicate caching strategy: " + c.getName())
+		}
+
+		names[c.getName()] = struct{}{}
+
+		C = append(C, c)
+	}
+
+	return C
 }
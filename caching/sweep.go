@@ -0,0 +1,194 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// sweepPoint is one combination of values of the swept parameters of a [[strategy]] entry
+type sweepPoint struct {
+	tree *toml.Tree
+	// appended to the name of the strategy, e.g., -CAPACITY-1000000
+	tag string
+}
+
+// getSweep returns one config per point of the grid spanned by the sweep table of a [[strategy]] entry
+// every key in the sweep table is a parameter with either a list of values or a range with from, to, and step:
+//
+//	[strategy.sweep]
+//	capacity = { from = 1000000, to = 5000000, step = 1000000 }
+//	eviction = ["LRU", "LFU"]
+//
+// an entry without a sweep table is a single point without a tag
+func getSweep(name string, t *toml.Tree) []sweepPoint {
+	if !t.Has("sweep") {
+		return []sweepPoint{{tree: t}}
+	}
+
+	sweep, ok := t.Get("sweep").(*toml.Tree)
+
+	if !ok {
+		panic(name + ": sweep must be a table")
+	}
+
+	keys := sweep.Keys()
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		panic(name + ": sweep must not be empty")
+	}
+
+	// the grid is built one parameter at a time, the last key changes fastest
+	type point struct {
+		values map[string]interface{}
+		tag    string
+	}
+
+	points := []point{{values: make(map[string]interface{})}}
+
+	for _, key := range keys {
+		if key == "type" || key == "sweep" {
+			panic(name + ": cannot sweep " + key)
+		}
+
+		var next []point
+
+		for _, p := range points {
+			for _, v := range getSweepValues(name, key, sweep.Get(key)) {
+				values := make(map[string]interface{}, len(p.values)+1)
+
+				for k, pv := range p.values {
+					values[k] = pv
+				}
+
+				values[key] = v
+
+				next = append(next, point{
+					values: values,
+					tag:    p.tag + "-" + strings.ToUpper(strings.Replace(key, "_", "-", -1)) + "-" + formatSweepValue(v),
+				})
+			}
+		}
+
+		points = next
+	}
+
+	sp := make([]sweepPoint, 0, len(points))
+
+	for _, p := range points {
+		m := t.ToMap()
+		delete(m, "sweep")
+
+		for k, v := range p.values {
+			m[k] = v
+		}
+
+		tree, err := toml.TreeFromMap(m)
+
+		if err != nil {
+			panic(err)
+		}
+
+		sp = append(sp, sweepPoint{
+			tree: tree,
+			tag:  p.tag,
+		})
+	}
+
+	return sp
+}
+
+// getSweepValues returns the values of one swept parameter
+func getSweepValues(name string, key string, v interface{}) []interface{} {
+	switch values := v.(type) {
+	case []interface{}:
+		if len(values) == 0 {
+			panic(name + ": sweep of " + key + " has no values")
+		}
+
+		return values
+	case *toml.Tree:
+		return getSweepRange(name+": sweep of "+key, values)
+	}
+
+	panic(name + ": sweep of " + key + " must be a list of values or a range with from, to, and step")
+}
+
+// getSweepRange returns all values from from to to (inclusive) in steps of step
+// the values are integers if all three are integers
+func getSweepRange(name string, t *toml.Tree) []interface{} {
+	r := configTable{
+		name: name,
+		tree: t,
+	}
+
+	for _, key := range []string{"from", "to", "step"} {
+		if !r.has(key) {
+			panic(name + ": missing " + key)
+		}
+	}
+
+	_, fromInt := t.Get("from").(int64)
+	_, toInt := t.Get("to").(int64)
+	_, stepInt := t.Get("step").(int64)
+
+	from := r.getFloat64("from", 0)
+	to := r.getFloat64("to", 0)
+	step := r.getFloat64("step", 0)
+
+	if step <= 0 || to < from {
+		panic(name + ": step must be positive and to must not be less than from")
+	}
+
+	// a little tolerance so that rounding errors do not cost us the last value
+	n := int64(math.Floor((to-from)/step+1e-9)) + 1
+
+	values := make([]interface{}, 0, n)
+
+	for i := int64(0); i < n; i++ {
+		if fromInt && toInt && stepInt {
+			values = append(values, t.Get("from").(int64)+i*t.Get("step").(int64))
+			continue
+		}
+
+		values = append(values, from+float64(i)*step)
+	}
+
+	return values
+}
+
+func formatSweepValue(v interface{}) string {
+	switch x := v.(type) {
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	}
+
+	panic("cannot sweep over a value of this type")
+}
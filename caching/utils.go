@@ -17,22 +17,10 @@
 
 package main
 
-import "strconv"
-
-func copyCache(cache map[int64]map[int64]struct{}) map[int64]map[int64]struct{} {
-	cp := make(map[int64]map[int64]struct{})
-
-	for node, localCache := range cache {
-
-		cp[node] = make(map[int64]struct{})
-
-		for item := range localCache {
-			cp[node][item] = struct{}{}
-		}
-	}
-
-	return cp
-}
+import (
+	"math"
+	"strconv"
+)
 
 func strToInt64(items *[]string) *[]int64 {
 	sp := make([]int64, len(*items))
@@ -49,3 +37,23 @@ func strToInt64(items *[]string) *[]int64 {
 
 	return &sp
 }
+
+// percentile of values sorted from low to high, like calcPercentile
+func percentile(val []float64, p int64) float64 {
+	q := float64(p) / 100.0
+
+	N := float64(len(val))
+
+	n := 1/3.0 + q*(N+1/3.0) // R8
+
+	kf, frac := math.Modf(n)
+
+	k := int(kf)
+
+	if k <= 0 {
+		return val[0]
+	} else if k >= len(val) {
+		return val[len(val)-1]
+	}
+	return val[k-1] + frac*(val[k]-val[k-1])
+}
@@ -42,6 +42,24 @@ if __name__ == "__main__":
 
     for file in tqdm.tqdm(os.listdir(results_folder), desc="Generating Graphs..."):
         filename = os.fsdecode(file)
+        # hit ratios per item popularity and size bucket from the analysis tool
+        if filename == "data.csvitemsummary.csv":
+            data = pandas.read_csv(os.path.join(results_folder, filename), dtype={"bucket": str})
+
+            for kind in data["kind"].unique():
+                for ratio in ["hit_ratio", "byte_hit_ratio"]:
+                    fig, ax = plt.subplots(figsize=[15.0, 5.0])
+
+                    sns.barplot(data=data[data["kind"] == kind], x="bucket", y=ratio, hue="strategy", ax=ax)
+
+                    fig.savefig(os.path.join(results_folder, filename + kind + ratio + ".png"), dpi=1000)
+
+                    plt.close(fig)
+
+            continue
+        # the summary is not a time series
+        if filename.endswith("summary.csv"):
+            continue
         if filename.endswith(".csv"):
             print(filename)
             # complete graph
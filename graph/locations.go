@@ -0,0 +1,302 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// locationTotals are the sums over all requests of one location for one strategy
+type locationTotals struct {
+	requests float64
+	bytes    float64
+	hits     float64
+	hitBytes float64
+	hops     float64
+	latency  float64
+}
+
+func (t *locationTotals) add(o *locationTotals) {
+	t.requests += o.requests
+	t.bytes += o.bytes
+	t.hits += o.hits
+	t.hitBytes += o.hitBytes
+	t.hops += o.hops
+	t.latency += o.latency
+}
+
+// writeTotals writes requests, bytes, hit ratio, byte hit ratio, avg hops, and avg latency
+func (t *locationTotals) writeTotals(buf *bufio.Writer) {
+	byteHitRatio := 0.0
+	if t.bytes > 0 {
+		byteHitRatio = t.hitBytes / t.bytes
+	}
+
+	buf.WriteString(strconv.FormatFloat(t.requests, 'f', -1, 64))
+	buf.WriteString(",")
+	buf.WriteString(strconv.FormatFloat(t.bytes, 'f', -1, 64))
+	buf.WriteString(",")
+	buf.WriteString(strconv.FormatFloat(t.hits/t.requests, 'f', -1, 64))
+	buf.WriteString(",")
+	buf.WriteString(strconv.FormatFloat(byteHitRatio, 'f', -1, 64))
+	buf.WriteString(",")
+	buf.WriteString(strconv.FormatFloat(t.hops/t.requests, 'f', -1, 64))
+	buf.WriteString(",")
+	buf.WriteString(strconv.FormatFloat(t.latency/t.requests, 'f', -1, 64))
+}
+
+type location struct {
+	gst        int64
+	name       string
+	population int64
+}
+
+// summarizeLocations sums up the locations files of all steps after the warm-up period
+// it writes one line per location and strategy, ranked by population, to data.csvlocationsummary.csv
+// and one line per population bin and strategy to data.csvpopulationsummary.csv
+// bins are powers of ten, e.g., all locations with 1000 to 9999 inhabitants
+func summarizeLocations(dataFiles string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64, warmup int64) {
+	locations := make(map[int64]*location)
+	totals := make(map[string]map[int64]*locationTotals)
+
+	for _, s := range strategies {
+		totals[s] = make(map[int64]*locationTotals)
+	}
+
+	for time := first; time < steps*stepLength; time += stepLength {
+		if time < warmup {
+			continue
+		}
+
+		for _, s := range strategies {
+			// runs from before there were locations files
+			if !readLocations(cacheFiles+strconv.FormatInt(time, 10)+s+"locations", locations, totals[s]) {
+				return
+			}
+		}
+	}
+
+	ranked := make([]*location, 0, len(locations))
+
+	for _, l := range locations {
+		ranked = append(ranked, l)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].population != ranked[j].population {
+			return ranked[i].population > ranked[j].population
+		}
+
+		return ranked[i].gst > ranked[j].gst
+	})
+
+	f, err := os.Create(dataFiles + "locationsummary.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("gst,name,population,rank,strategy,requests,bytes,hit_ratio,byte_hit_ratio,avg_hops,avg_latency\n")
+
+	for rank, l := range ranked {
+		for _, s := range strategies {
+			t, ok := totals[s][l.gst]
+
+			if !ok {
+				continue
+			}
+
+			buf.WriteString(strconv.FormatInt(l.gst, 10))
+			buf.WriteString(",")
+			buf.WriteString(l.name)
+			buf.WriteString(",")
+			buf.WriteString(strconv.FormatInt(l.population, 10))
+			buf.WriteString(",")
+			buf.WriteString(strconv.Itoa(rank + 1))
+			buf.WriteString(",")
+			buf.WriteString(s)
+			buf.WriteString(",")
+			t.writeTotals(buf)
+			buf.WriteString("\n")
+		}
+	}
+
+	buf.Flush()
+
+	// bins, from the smallest locations to the largest
+	bins := []int64{}
+	binTotals := make(map[string]map[int64]*locationTotals)
+	binLocations := make(map[int64]int64)
+
+	for _, s := range strategies {
+		binTotals[s] = make(map[int64]*locationTotals)
+	}
+
+	for i := len(ranked) - 1; i >= 0; i-- {
+		l := ranked[i]
+		b := populationBin(l.population)
+
+		if _, ok := binLocations[b]; !ok {
+			bins = append(bins, b)
+		}
+
+		binLocations[b]++
+
+		for _, s := range strategies {
+			t, ok := totals[s][l.gst]
+
+			if !ok {
+				continue
+			}
+
+			if _, ok := binTotals[s][b]; !ok {
+				binTotals[s][b] = &locationTotals{}
+			}
+
+			binTotals[s][b].add(t)
+		}
+	}
+
+	f, err = os.Create(dataFiles + "populationsummary.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf = bufio.NewWriter(f)
+
+	buf.WriteString("min_population,max_population,locations,strategy,requests,bytes,hit_ratio,byte_hit_ratio,avg_hops,avg_latency\n")
+
+	for _, b := range bins {
+		for _, s := range strategies {
+			t, ok := binTotals[s][b]
+
+			if !ok {
+				continue
+			}
+
+			buf.WriteString(strconv.FormatInt(b, 10))
+			buf.WriteString(",")
+			buf.WriteString(strconv.FormatInt(b*10-1, 10))
+			buf.WriteString(",")
+			buf.WriteString(strconv.FormatInt(binLocations[b], 10))
+			buf.WriteString(",")
+			buf.WriteString(s)
+			buf.WriteString(",")
+			t.writeTotals(buf)
+			buf.WriteString("\n")
+		}
+	}
+
+	buf.Flush()
+}
+
+// populationBin is the power of ten at or below the population, 0 for locations without inhabitants
+func populationBin(population int64) int64 {
+	if population <= 0 {
+		return 0
+	}
+
+	b := int64(1)
+
+	for b*10 <= population {
+		b *= 10
+	}
+
+	return b
+}
+
+// readLocations adds the lines of a locations file to the totals of a strategy
+// it returns false if the file does not exist
+func readLocations(file string, locations map[int64]*location, totals map[int64]*locationTotals) bool {
+	c, err := os.Open(file)
+
+	if os.IsNotExist(err) {
+		return false
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer c.Close()
+
+	csvr := csv.NewReader(c)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		gst, err := strconv.ParseInt(line[0], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		// population, requests, bytes, hit_ratio, byte_hit_ratio, avg_hops, avg_latency
+		v := make([]float64, len(line))
+
+		for i := 2; i < len(line); i++ {
+			v[i], err = strconv.ParseFloat(line[i], 64)
+
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		if _, ok := locations[gst]; !ok {
+			locations[gst] = &location{
+				gst:        gst,
+				name:       line[1],
+				population: int64(v[2]),
+			}
+		}
+
+		if _, ok := totals[gst]; !ok {
+			totals[gst] = &locationTotals{}
+		}
+
+		totals[gst].add(&locationTotals{
+			requests: v[3],
+			bytes:    v[4],
+			hits:     v[5] * v[3],
+			hitBytes: v[6] * v[4],
+			hops:     v[7] * v[3],
+			latency:  v[8] * v[3],
+		})
+	}
+
+	return true
+}
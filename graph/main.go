@@ -29,9 +29,9 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
-func getTx(txFile string, cacheFiles string, strategies []string, steps int64, stepLength int64) {
+func getTx(txFile string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
 
-	attr := []string{"total", "max", "min", "avg", "median", "95th", "99th"}
+	attr := []string{"total", "max", "min", "avg", "median", "95th", "99th", "total_request", "total_prefetch", "total_placement", "total_invalidation", "total_migration"}
 
 	bufs := make(map[string]*bufio.Writer)
 
@@ -58,11 +58,9 @@ func getTx(txFile string, cacheFiles string, strategies []string, steps int64, s
 		buf.Flush()
 	}
 
-	pbar := progressbar.Default(steps)
-
-	var time int64
+	pbar := progressbar.Default(steps - first/stepLength)
 
-	for ; time < steps*stepLength; time += stepLength {
+	for time := first; time < steps*stepLength; time += stepLength {
 		ts := strconv.FormatInt(time, 10)
 		for _, buf := range bufs {
 			buf.WriteString(ts)
@@ -98,7 +96,7 @@ func getTx(txFile string, cacheFiles string, strategies []string, steps int64, s
 	}
 }
 
-func getStore(storeFile string, cacheFiles string, strategies []string, steps int64, stepLength int64) {
+func getStore(storeFile string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
 
 	attr := []string{"total", "max", "min", "avg", "median", "95th", "99th", "numnodes", "numnostorenodes"}
 
@@ -127,11 +125,9 @@ func getStore(storeFile string, cacheFiles string, strategies []string, steps in
 		buf.Flush()
 	}
 
-	pbar := progressbar.Default(steps)
-
-	var time int64
+	pbar := progressbar.Default(steps - first/stepLength)
 
-	for ; time < steps*stepLength; time += stepLength {
+	for time := first; time < steps*stepLength; time += stepLength {
 		ts := strconv.FormatInt(time, 10)
 		for _, buf := range bufs {
 			buf.WriteString(ts)
@@ -167,9 +163,9 @@ func getStore(storeFile string, cacheFiles string, strategies []string, steps in
 	}
 }
 
-func getCache(cacheFile string, cacheFiles string, strategies []string, steps int64, stepLength int64) {
+func getCache(cacheFile string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
 
-	attr := []string{"ratio", "num_requests"}
+	attr := []string{"ratio", "byte_ratio", "num_requests", "stale_ratio", "failed_ratio", "failed_hit_ratio_change", "warmup"}
 
 	bufs := make(map[string]*bufio.Writer)
 
@@ -196,11 +192,9 @@ func getCache(cacheFile string, cacheFiles string, strategies []string, steps in
 		buf.Flush()
 	}
 
-	pbar := progressbar.Default(steps)
+	pbar := progressbar.Default(steps - first/stepLength)
 
-	var time int64
-
-	for ; time < steps*stepLength; time += stepLength {
+	for time := first; time < steps*stepLength; time += stepLength {
 		ts := strconv.FormatInt(time, 10)
 		for _, buf := range bufs {
 			buf.WriteString(ts)
@@ -236,7 +230,7 @@ func getCache(cacheFile string, cacheFiles string, strategies []string, steps in
 	}
 }
 
-func getHops(hopsFile string, cacheFiles string, strategies []string, steps int64, stepLength int64) {
+func getHops(hopsFile string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
 
 	attr := []string{"total", "max", "min", "avg", "median", "95th", "99th"}
 
@@ -265,11 +259,9 @@ func getHops(hopsFile string, cacheFiles string, strategies []string, steps int6
 		buf.Flush()
 	}
 
-	pbar := progressbar.Default(steps)
-
-	var time int64
+	pbar := progressbar.Default(steps - first/stepLength)
 
-	for ; time < steps*stepLength; time += stepLength {
+	for time := first; time < steps*stepLength; time += stepLength {
 		ts := strconv.FormatInt(time, 10)
 		for _, buf := range bufs {
 			buf.WriteString(ts)
@@ -305,13 +297,219 @@ func getHops(hopsFile string, cacheFiles string, strategies []string, steps int6
 	}
 }
 
+func getLatency(latencyFile string, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
+
+	attr := []string{"avg", "median", "95th", "99th"}
+
+	bufs := make(map[string]*bufio.Writer)
+
+	for _, a := range attr {
+		f, err := os.Create(latencyFile + a + ".csv")
+
+		if err != nil {
+			panic(err)
+		}
+
+		defer f.Close()
+		buf := bufio.NewWriter(f)
+
+		buf.WriteString("time")
+
+		for _, s := range strategies {
+			buf.WriteString(",")
+			buf.WriteString(s)
+		}
+
+		buf.WriteString("\n")
+
+		bufs[a] = buf
+		buf.Flush()
+	}
+
+	pbar := progressbar.Default(steps - first/stepLength)
+
+	for time := first; time < steps*stepLength; time += stepLength {
+		ts := strconv.FormatInt(time, 10)
+		for _, buf := range bufs {
+			buf.WriteString(ts)
+		}
+
+		for _, s := range strategies {
+			for _, buf := range bufs {
+				buf.WriteString(",")
+			}
+
+			file := cacheFiles + ts + s + "latency"
+			c, err := os.Open(file)
+
+			if err != nil {
+				panic(err)
+			}
+
+			csvr := csv.NewReader(c)
+
+			for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+				bufs[line[0]].WriteString(line[1])
+			}
+
+			c.Close()
+		}
+
+		for _, buf := range bufs {
+			buf.WriteString("\n")
+			buf.Flush()
+		}
+		pbar.Add(1)
+
+	}
+}
+
+// readMetrics reads a metrics file, or returns nil if the strategy has no metrics
+func readMetrics(file string) (map[string]string, []string) {
+	c, err := os.Open(file)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer c.Close()
+
+	metrics := make(map[string]string)
+	names := []string{}
+
+	csvr := csv.NewReader(c)
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		metrics[line[0]] = line[1]
+		names = append(names, line[0])
+	}
+
+	return metrics, names
+}
+
+// getMetrics is like the other functions, but the attributes depend on the strategies
+// strategies that do not have a metric get an empty column
+// record is the kind of cache file the metrics are in, e.g., metrics or links
+// if allSteps is set, metrics are collected from all steps instead of only the first, e.g., for origins that are
+// not requested in every step
+func getMetrics(metricsFile string, record string, allSteps bool, cacheFiles string, strategies []string, first int64, steps int64, stepLength int64) {
+
+	attr := []string{}
+	seen := make(map[string]struct{})
+
+	last := first + stepLength
+	if allSteps {
+		last = steps * stepLength
+	}
+
+	for time := first; time < last; time += stepLength {
+		for _, s := range strategies {
+			_, names := readMetrics(cacheFiles + strconv.FormatInt(time, 10) + s + record)
+
+			for _, n := range names {
+				if _, ok := seen[n]; !ok {
+					seen[n] = struct{}{}
+					attr = append(attr, n)
+				}
+			}
+		}
+	}
+
+	// no strategy has any metrics
+	if len(attr) == 0 {
+		return
+	}
+
+	bufs := make(map[string]*bufio.Writer)
+
+	for _, a := range attr {
+		f, err := os.Create(metricsFile + a + ".csv")
+
+		if err != nil {
+			panic(err)
+		}
+
+		defer f.Close()
+		buf := bufio.NewWriter(f)
+
+		buf.WriteString("time")
+
+		for _, s := range strategies {
+			buf.WriteString(",")
+			buf.WriteString(s)
+		}
+
+		buf.WriteString("\n")
+
+		bufs[a] = buf
+		buf.Flush()
+	}
+
+	pbar := progressbar.Default(steps - first/stepLength)
+
+	for time := first; time < steps*stepLength; time += stepLength {
+		ts := strconv.FormatInt(time, 10)
+		for _, buf := range bufs {
+			buf.WriteString(ts)
+		}
+
+		for _, s := range strategies {
+			metrics, _ := readMetrics(cacheFiles + ts + s + record)
+
+			for a, buf := range bufs {
+				buf.WriteString(",")
+				buf.WriteString(metrics[a])
+			}
+		}
+
+		for _, buf := range bufs {
+			buf.WriteString("\n")
+			buf.Flush()
+		}
+		pbar.Add(1)
+
+	}
+}
+
+// getStrategies reads the list of strategies the caching command has used
+func getStrategies(strategiesFile string) []string {
+	s, err := os.Open(strategiesFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.Close()
+
+	csvr := csv.NewReader(s)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	strategies := []string{}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		strategies = append(strategies, line[0])
+	}
+
+	return strategies
+}
+
 func main() {
 	if len(os.Args) != 2 {
 		panic("not enough arguments given")
 	}
 
-	strategies := []string{"NONE", "GROUND-STATION-1000000000", "GROUND-STATION-10000", "GROUND-STATION-100", "GROUND-STATION-10", "SATELLITE", "SATELLITE-TIMEOUT", "SATELLITE-VIRTUAL"}
-
 	conf := os.Args[1]
 
 	config, err := toml.LoadFile(conf)
@@ -341,17 +539,41 @@ func main() {
 
 	cacheFiles := path.Join(workloadFolder, "cache", "c.csv")
 
+	strategies := getStrategies(path.Join(workloadFolder, "cache", "strategies.csv"))
+
 	err = os.MkdirAll(path.Join(workloadFolder, "data"), os.ModePerm)
 
 	if err != nil {
 		panic(err)
 	}
 
+	warmup, dropWarmup := getWarmup(path.Join(workloadFolder, "cache", "warmup.csv"))
+
+	// without records for the warm-up period, we start with the first step after it
+	var first int64
+	if dropWarmup {
+		first = (warmup + stepLength - 1) / stepLength * stepLength
+	}
+
 	dataFiles := path.Join(workloadFolder, "data", "data.csv")
 
-	getTx(dataFiles+"tx", cacheFiles, strategies, steps, stepLength)
-	getStore(dataFiles+"store", cacheFiles, strategies, steps, stepLength)
-	getCache(dataFiles+"cache", cacheFiles, strategies, steps, stepLength)
-	getHops(dataFiles+"hops", cacheFiles, strategies, steps, stepLength)
+	getTx(dataFiles+"tx", cacheFiles, strategies, first, steps, stepLength)
+	getStore(dataFiles+"store", cacheFiles, strategies, first, steps, stepLength)
+	getCache(dataFiles+"cache", cacheFiles, strategies, first, steps, stepLength)
+	getHops(dataFiles+"hops", cacheFiles, strategies, first, steps, stepLength)
+	getLatency(dataFiles+"latency", cacheFiles, strategies, first, steps, stepLength)
+	getMetrics(dataFiles+"metrics", "metrics", false, cacheFiles, strategies, first, steps, stepLength)
+	getMetrics(dataFiles+"links", "links", false, cacheFiles, strategies, first, steps, stepLength)
+	getMetrics(dataFiles+"energy", "energy", false, cacheFiles, strategies, first, steps, stepLength)
+	getMetrics(dataFiles+"origins", "origins", true, cacheFiles, strategies, first, steps, stepLength)
+
+	summarizeLocations(dataFiles, cacheFiles, strategies, first, steps, stepLength, warmup)
+
+	summarizeRun(dataFiles, cacheFiles, strategies)
+
+	summarize(dataFiles, strategies, warmup)
+
+	bases, replications := getReplications(path.Join(workloadFolder, "cache", "strategies.csv"))
+	summarizeReplications(dataFiles, bases, replications, strategies, warmup)
 
 }
@@ -0,0 +1,111 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+)
+
+// summarizeRun puts the whole-run percentiles the caching tool writes when it is done into data.csvrunsummary.csv
+// there is one line per value and attribute, e.g., the 99th pcntl of hops, with one column per strategy
+// runs without a summary, e.g., older ones, are skipped
+func summarizeRun(dataFiles string, cacheFiles string, strategies []string) {
+	summaryFile, err := os.Open(cacheFiles + "summary")
+
+	if os.IsNotExist(err) {
+		return
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer summaryFile.Close()
+
+	csvr := csv.NewReader(summaryFile)
+
+	header, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	// the first two columns are strategy and value
+	attr := header[2:]
+
+	values := []string{}
+	// value -> strategy -> attribute values
+	stats := make(map[string]map[string][]string)
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		s, v := line[0], line[1]
+
+		if _, ok := stats[v]; !ok {
+			stats[v] = make(map[string][]string)
+			values = append(values, v)
+		}
+
+		stats[v][s] = line[2:]
+	}
+
+	f, err := os.Create(dataFiles + "runsummary.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("value,attribute")
+
+	for _, s := range strategies {
+		buf.WriteString(",")
+		buf.WriteString(s)
+	}
+
+	buf.WriteString("\n")
+
+	for _, v := range values {
+		for i, a := range attr {
+			buf.WriteString(v)
+			buf.WriteString(",")
+			buf.WriteString(a)
+
+			for _, s := range strategies {
+				buf.WriteString(",")
+
+				if line, ok := stats[v][s]; ok {
+					buf.WriteString(line[i])
+				}
+			}
+
+			buf.WriteString("\n")
+		}
+	}
+
+	buf.Flush()
+}
@@ -0,0 +1,391 @@
+/*
+* This file is part of LLEOSCN-CDN-Sim (https://github.com/pfandzelter/LLEOSCN-CDN-Sim).
+* Copyright (c) 2020 Tobias Pfandzelter.
+*
+* This program is free software: you can redistribute it and/or modify
+* it under the terms of the GNU General Public License as published by
+* the Free Software Foundation, version 3.
+*
+* This program is distributed in the hope that it will be useful, but
+* WITHOUT ANY WARRANTY; without even the implied warranty of
+* MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU
+* General Public License for more details.
+*
+* You should have received a copy of the GNU General Public License
+* along with this program. If not, see <http://www.gnu.org/licenses/>.
+**/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// getWarmup reads the end of the warm-up period and whether its records were dropped, as the caching command resolved them
+func getWarmup(warmupFile string) (int64, bool) {
+	w, err := os.Open(warmupFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer w.Close()
+
+	csvr := csv.NewReader(w)
+
+	// skip header
+	if _, err = csvr.Read(); err != nil {
+		panic(err)
+	}
+
+	line, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	warmup, err := strconv.ParseInt(line[0], 10, 64)
+
+	if err != nil {
+		panic(err)
+	}
+
+	switch line[1] {
+	case "TAG":
+		return warmup, false
+	case "DROP":
+		return warmup, true
+	}
+
+	panic("unknown warm-up records " + line[1] + " in " + warmupFile)
+}
+
+// summarize writes the mean of every time series in the data folder, separately for the warm-up period and the rest of the run
+// every series has two rows, e.g., "cacheratio,warmup,..." and "cacheratio,steady,..."
+// values that are missing or not a number are left out
+func summarize(dataFiles string, strategies []string, warmup int64) {
+	summaryFile := dataFiles + "summary.csv"
+
+	files, err := filepath.Glob(dataFiles + "*.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	sort.Strings(files)
+
+	f, err := os.Create(summaryFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("series,period")
+
+	for _, s := range strategies {
+		buf.WriteString(",")
+		buf.WriteString(s)
+	}
+
+	buf.WriteString("\n")
+
+	for _, file := range files {
+		// neither this summary nor the one of the replications are time series
+		if strings.HasSuffix(file, "summary.csv") {
+			continue
+		}
+
+		series := strings.TrimSuffix(strings.TrimPrefix(file, dataFiles), ".csv")
+
+		warmupMeans, steadyMeans := readMeans(file, strategies, warmup)
+
+		for _, p := range []struct {
+			name  string
+			means map[string]float64
+		}{{"warmup", warmupMeans}, {"steady", steadyMeans}} {
+			buf.WriteString(series)
+			buf.WriteString(",")
+			buf.WriteString(p.name)
+
+			for _, s := range strategies {
+				buf.WriteString(",")
+
+				if m, ok := p.means[s]; ok {
+					buf.WriteString(strconv.FormatFloat(m, 'f', -1, 64))
+				}
+			}
+
+			buf.WriteString("\n")
+		}
+	}
+
+	buf.Flush()
+}
+
+// readMeans reads a time series and returns the mean of each strategy before and after the end of the warm-up period
+func readMeans(file string, strategies []string, warmup int64) (map[string]float64, map[string]float64) {
+	c, err := os.Open(file)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer c.Close()
+
+	csvr := csv.NewReader(c)
+
+	header, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	var sums [2]map[string]float64
+	var counts [2]map[string]int64
+
+	for i := range sums {
+		sums[i] = make(map[string]float64)
+		counts[i] = make(map[string]int64)
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		time, err := strconv.ParseInt(line[0], 10, 64)
+
+		if err != nil {
+			panic(err)
+		}
+
+		period := 1
+		if time < warmup {
+			period = 0
+		}
+
+		for i := 1; i < len(line) && i < len(header); i++ {
+			v, err := strconv.ParseFloat(line[i], 64)
+
+			if err != nil || math.IsNaN(v) {
+				continue
+			}
+
+			sums[period][header[i]] += v
+			counts[period][header[i]]++
+		}
+	}
+
+	var means [2]map[string]float64
+
+	for i := range means {
+		means[i] = make(map[string]float64)
+
+		for _, s := range strategies {
+			if counts[i][s] > 0 {
+				means[i][s] = sums[i][s] / float64(counts[i][s])
+			}
+		}
+	}
+
+	return means[0], means[1]
+}
+
+// tQuantiles are the 97.5th percentiles of the t-distribution for 1 to 30 degrees of freedom
+// for more, the normal distribution is close enough
+var tQuantiles = []float64{12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228, 2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042}
+
+// getReplications reads which strategies are replications of the same entry with different seeds
+// it returns the base names in order and the names of the replications of each
+// strategy files without a base column have no replications
+func getReplications(strategiesFile string) ([]string, map[string][]string) {
+	s, err := os.Open(strategiesFile)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.Close()
+
+	csvr := csv.NewReader(s)
+
+	header, err := csvr.Read()
+
+	if err != nil {
+		panic(err)
+	}
+
+	base := -1
+
+	for i, h := range header {
+		if h == "base" {
+			base = i
+		}
+	}
+
+	bases := []string{}
+	replications := make(map[string][]string)
+
+	if base < 0 {
+		return bases, replications
+	}
+
+	for line, err := csvr.Read(); err != io.EOF; line, err = csvr.Read() {
+		if err != nil {
+			panic(err)
+		}
+
+		if _, ok := replications[line[base]]; !ok {
+			bases = append(bases, line[base])
+		}
+
+		replications[line[base]] = append(replications[line[base]], line[0])
+	}
+
+	return bases, replications
+}
+
+// summarizeReplications writes the mean of every time series over all replications of a strategy and its 95% confidence interval
+// each replication contributes the mean of its time series, separately for the warm-up period and the rest of the run
+// every series and period has two rows, "mean" and "ci95", which is the half width of the confidence interval
+// nothing is written if no strategy has more than one replication
+func summarizeReplications(dataFiles string, bases []string, replications map[string][]string, strategies []string, warmup int64) {
+	replicated := false
+
+	for _, b := range bases {
+		if len(replications[b]) > 1 {
+			replicated = true
+		}
+	}
+
+	if !replicated {
+		return
+	}
+
+	files, err := filepath.Glob(dataFiles + "*.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	sort.Strings(files)
+
+	f, err := os.Create(dataFiles + "replicationsummary.csv")
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+
+	buf.WriteString("series,period,statistic")
+
+	for _, b := range bases {
+		buf.WriteString(",")
+		buf.WriteString(b)
+	}
+
+	buf.WriteString("\n")
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "summary.csv") {
+			continue
+		}
+
+		series := strings.TrimSuffix(strings.TrimPrefix(file, dataFiles), ".csv")
+
+		warmupMeans, steadyMeans := readMeans(file, strategies, warmup)
+
+		for _, p := range []struct {
+			name  string
+			means map[string]float64
+		}{{"warmup", warmupMeans}, {"steady", steadyMeans}} {
+			mean := make(map[string]float64)
+			ci := make(map[string]float64)
+
+			for _, b := range bases {
+				var values []float64
+
+				for _, r := range replications[b] {
+					if m, ok := p.means[r]; ok {
+						values = append(values, m)
+					}
+				}
+
+				if len(values) == 0 {
+					continue
+				}
+
+				mean[b], ci[b] = confidenceInterval(values)
+			}
+
+			for _, stat := range []struct {
+				name   string
+				values map[string]float64
+			}{{"mean", mean}, {"ci95", ci}} {
+				buf.WriteString(series)
+				buf.WriteString(",")
+				buf.WriteString(p.name)
+				buf.WriteString(",")
+				buf.WriteString(stat.name)
+
+				for _, b := range bases {
+					buf.WriteString(",")
+
+					if v, ok := stat.values[b]; ok && !math.IsNaN(v) {
+						buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+					}
+				}
+
+				buf.WriteString("\n")
+			}
+		}
+	}
+
+	buf.Flush()
+}
+
+// confidenceInterval returns the mean of the values and the half width of its 95% confidence interval
+// with a single value, the width is unknown and NaN
+func confidenceInterval(values []float64) (float64, float64) {
+	n := len(values)
+
+	var sum float64
+
+	for _, v := range values {
+		sum += v
+	}
+
+	mean := sum / float64(n)
+
+	if n < 2 {
+		return mean, math.NaN()
+	}
+
+	var squares float64
+
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+
+	t := 1.96
+	if n-1 <= len(tQuantiles) {
+		t = tQuantiles[n-2]
+	}
+
+	return mean, t * math.Sqrt(squares/float64(n-1)/float64(n))
+}
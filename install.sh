@@ -36,3 +36,9 @@ export PATH=/usr/local/go/bin/:\$PATH
   go get ./...
   go build .
 )
+
+(
+  cd ./graph || exit
+  go get ./...
+  go build .
+)
@@ -18,6 +18,7 @@
 import numpy as np
 import pandas
 import os
+import shutil
 from tqdm import trange, tqdm
 import multiprocessing as mp
 import toml
@@ -46,7 +47,7 @@ def single_workload(load_file, city_file, request_amount, time):
 
 def gen_load(base_path, workload):
     # load file
-    # id (of item) | origin | size (in bytes)
+    # id (of item) | origin | pop | size (in bytes) | update_interval (in s, 0 if the item never changes)
     #
     # workload file
     # source (from cities) | id (id of item to retrieve) | time (in s)
@@ -89,17 +90,69 @@ def gen_load(base_path, workload):
         "size": np.around(np.power(10., np.random.default_rng(0).normal(loc=1.25, scale=0.5,size=workload["item_amount"])) * 1000)
     })
 
+    # some items, e.g., news or api responses, change regularly
+    # update_fraction of items get a new version every update_interval seconds, all others never change
+    update_fraction = workload.get("update_fraction", 0.0)
+    update_interval = workload.get("update_interval", 300)
+    items["update_interval"] = np.where(np.random.default_rng(0).random(workload["item_amount"]) < update_fraction, update_interval, 0)
+
     items.to_csv(os.path.join(base_path, "load.csv"), index=False)
 
+    workload_config = {
+        "step_length": workload["step_length"],
+        "steps": workload["steps"],
+        "requestamount": workload["request_amount"],
+        "locations": "locations.csv",
+        "cities": "cities.csv",
+        "loadfile": "load.csv"
+    }
+
+    # constellation geometry, if not given the first starlink shell is used
+    if "constellation" in workload:
+        workload_config["constellation"] = workload["constellation"]
+
+    # caching strategies to compare, if not given the caching tool uses its defaults
+    if "strategy" in workload:
+        workload_config["strategy"] = workload["strategy"]
+
+    # warm-up period whose records the caching tool tags or drops
+    for key in ["warmup_steps", "warmup_seconds", "warmup_records"]:
+        if key in workload:
+            workload_config[key] = workload[key]
+
+    # how often the caching tool writes a checkpoint it can resume from
+    if "checkpoint_steps" in workload:
+        workload_config["checkpoint_steps"] = workload["checkpoint_steps"]
+
+    # seed of the random numbers strategies draw and how many runs with different seeds there are
+    for key in ["seed", "replications"]:
+        if key in workload:
+            workload_config[key] = workload[key]
+
+    # satellite and link failures, a failure scenario is copied into the workload folder where the caching tool looks for it
+    if "failures" in workload:
+        failures = dict(workload["failures"])
+
+        if "file" in failures:
+            shutil.copy(failures["file"], os.path.join(base_path, os.path.basename(failures["file"])))
+            failures["file"] = os.path.basename(failures["file"])
+
+        workload_config["failures"] = failures
+
+    # link capacities in bytes per second
+    if "links" in workload:
+        workload_config["links"] = workload["links"]
+
+    # processing and queueing delay per hop
+    if "latency" in workload:
+        workload_config["latency"] = workload["latency"]
+
+    # energy per byte sent and per byte stored
+    if "energy" in workload:
+        workload_config["energy"] = workload["energy"]
+
     with open(os.path.join(base_path, "config.toml"), "w") as f:
-        toml.dump({
-            "step_length": workload["step_length"],
-            "steps": workload["steps"],
-            "requestamount": workload["request_amount"],
-            "locations": "locations.csv",
-            "cities": "cities.csv",
-            "loadfile": "load.csv"
-        }, f)
+        toml.dump(workload_config, f)
 
 if __name__ == "__main__":
     try:
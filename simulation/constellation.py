@@ -180,7 +180,9 @@ class Constellation():
             minCommunicationsAltitude=100000,
             minSatElevation=40,
             linkingMethod="GRID+",
-            arcOfAscendingNodes=360.0):
+            arcOfAscendingNodes=360.0,
+            phasing=None,
+            earthRotationPeriod=SECONDS_PER_DAY):
         """
         Parameters
         ----------
@@ -207,6 +209,12 @@ class Constellation():
             The angle of arc (in degrees) that the ascending nodes of all the
             orbital planes is evenly spaced along. Ex, seting this to 180 results
             in a Pi constellation like Iridium
+        phasing : int
+            The Walker phasing factor F. Adjacent planes are offset by
+            F * 360 / (planes * nodes_per_plane) degrees. If None, planes are
+            staggered so that adjacent planes have similar offsets.
+        earthRotationPeriod : float
+            The number of seconds per earth rotation.
         """
 
         self.number_of_planes = planes
@@ -215,6 +223,8 @@ class Constellation():
         self.ground_node_counter = 0
         self.inclination = inclination
         self.semi_major_axis = semi_major_axis
+        self.phasing = phasing
+        self.earth_rotation_period = earthRotationPeriod
         self.period = self.calculateOrbitPeriod(semi_major_axis=self.semi_major_axis)
         self.eccentricity = ecc
         self.current_time = 0
@@ -270,6 +280,14 @@ class Constellation():
 
         """
 
+        # with a walker phasing factor, each plane is offset by F times
+        # the time between two satellites in the whole constellation
+        if self.phasing is not None:
+            phase_offsets = [(self.period / self.total_sats) * self.phasing * plane
+                             for plane in range(self.number_of_planes)]
+            self.placeSatellites(phase_offsets)
+            return
+
         # we offset each plane by a small amount, so they do not "collide"
         # this little algorithm comes up with a list of offset values
         phase_offset = 0
@@ -303,6 +321,18 @@ class Constellation():
         #     if i_2 < (len(temp)):
         #         phase_offsets.append(temp[i_2])
 
+        self.placeSatellites(phase_offsets)
+
+    def placeSatellites(self, phase_offsets):
+        """places all satellites at their positions at time zero
+
+        Parameters
+        ----------
+        phase_offsets : List[float]
+            time offset of the first satellite in each plane
+
+        """
+
         # loop through all satellites
         for plane in range(0, self.number_of_planes):
             for node in range(0, self.nodes_per_plane):
@@ -406,11 +436,11 @@ class Constellation():
         # update all the satellite positions
         # rotate the pos to time = 0 as we consider the earth's positions then
 
-        if self.current_time == 0 or self.current_time % SECONDS_PER_DAY == 0:
+        if self.current_time == 0 or self.current_time % self.earth_rotation_period == 0:
             degrees_to_rotate = 0
         else:
-            degrees_to_rotate = 360.0/(SECONDS_PER_DAY /
-                                       (self.current_time % SECONDS_PER_DAY))
+            degrees_to_rotate = 360.0/(self.earth_rotation_period /
+                                       (self.current_time % self.earth_rotation_period))
 
         neg_rotation_matrix = self.getRotationMatrix(EARTH_ROTATION_AXIS,
                                                  -degrees_to_rotate)
@@ -606,13 +636,13 @@ class Constellation():
         unique_id = self.ground_node_counter
 
         # if simulation time is not 0, figure out current position
-        if self.current_time == 0 or self.current_time % SECONDS_PER_DAY == 0:
+        if self.current_time == 0 or self.current_time % self.earth_rotation_period == 0:
             degrees_to_rotate = 0
             pos = init_pos
 
         else:
-            degrees_to_rotate = 360.0/(SECONDS_PER_DAY /
-                                       (self.current_time % SECONDS_PER_DAY))
+            degrees_to_rotate = 360.0/(self.earth_rotation_period /
+                                       (self.current_time % self.earth_rotation_period))
 
             rotation_matrix = self.getRotationMatrix(EARTH_ROTATION_AXIS,
                                                      degrees_to_rotate)
@@ -28,7 +28,7 @@ from simulation import Simulation
 sys.path.append(os.path.abspath(os.getcwd()))
 from loadgenerator.gen_load import single_workload
 
-def simulate(steps, step_length, loc_file, result_file, load_file, city_file, request_amount):
+def simulate(steps, step_length, loc_file, result_file, load_file, city_file, request_amount, constellation):
 
     # constants
     # turning on animation is not recommended with more than 1 chunk
@@ -37,18 +37,22 @@ def simulate(steps, step_length, loc_file, result_file, load_file, city_file, re
     EARTH_RADIUS = 6371000
 
     # Number of planes
-    # PLANES = 24
-    PLANES = 24
+    PLANES = constellation.get("planes", 24)
 
     # Number of nodes/plane
-    # NODES = 66
-    NODES = 66
+    NODES = constellation.get("sats_per_plane", 66)
 
     # Plane inclination (deg)
-    INC = 53.0
+    INC = constellation.get("inclination", 53.0)
 
     # Orbit Altitude (Km)
-    ALTITUDE = 550
+    ALTITUDE = constellation.get("altitude", 550)
+
+    # Walker phasing factor, if not set planes are staggered by a fraction of the satellite spacing
+    PHASING = constellation.get("phasing", None)
+
+    # Seconds per earth rotation
+    EARTH_ROTATION_PERIOD = constellation.get("earth_rotation_period", 86400)
 
     # if true, will enable calculating network link-state
     MAKE_LINKS = True
@@ -62,7 +66,7 @@ def simulate(steps, step_length, loc_file, result_file, load_file, city_file, re
     # Cache strategy to use
     CACHE_STRATEGY = ["NONE", "GROUND-STATION", "SATELLITE", "SATELLITE-TIMEOUT", "VIRTUAL-POP"]
 
-    s = Simulation(planes=int(PLANES), nodesPerPlane=int(NODES), inclination=float(INC), semiMajorAxis=float(ALTITUDE)*1000 + EARTH_RADIUS, timeStep=int(step_length), makeLinks=MAKE_LINKS, linkingMethod=LINKING_METHOD, captureImages=False, frequency=FREQUENCY, groundPtsFile=loc_file, animate=ANIMATE,enablePathCalc=True)
+    s = Simulation(planes=int(PLANES), nodesPerPlane=int(NODES), inclination=float(INC), semiMajorAxis=float(ALTITUDE)*1000 + EARTH_RADIUS, timeStep=int(step_length), makeLinks=MAKE_LINKS, linkingMethod=LINKING_METHOD, captureImages=False, frequency=FREQUENCY, groundPtsFile=loc_file, animate=ANIMATE,enablePathCalc=True, phasing=PHASING, earthRotationPeriod=float(EARTH_ROTATION_PERIOD))
 
     for step in tqdm(steps, desc="simulating"):
         next_time = step*step_length
@@ -100,6 +104,8 @@ if __name__ == "__main__":
 
     request_amount = cfg["requestamount"]
 
+    constellation = cfg.get("constellation", {})
+
     result_file = os.path.join(workload_folder, "results", "r.csv")
     try:
         os.makedirs(os.path.join(workload_folder, "results"), exist_ok=True)
@@ -124,7 +130,8 @@ if __name__ == "__main__":
             "result_file": result_file,
             "load_file": load_file,
             "city_file": city_file,
-            "request_amount": request_amount
+            "request_amount": request_amount,
+            "constellation": constellation
         }
 
         p = mp.Process(target=simulate, kwargs=kw)
@@ -115,13 +115,17 @@ class Simulation():
             captureInterpolation=1,
             groundPtsFile="city_data.txt",
             enablePathCalc=False,
-            report_status=False):
+            report_status=False,
+            phasing=None,
+            earthRotationPeriod=SECONDS_PER_DAY):
 
         # constillation structure information
         self.num_planes = planes
         self.num_nodes_per_plane = nodesPerPlane
         self.plane_inclination = inclination
         self.semi_major_axis = semiMajorAxis
+        self.phasing = phasing
+        self.earth_rotation_period = earthRotationPeriod
         self.min_communications_altitude = 100000
         self.min_sat_elevation = MIN_SAT_ELEVATION
 
@@ -161,7 +165,9 @@ class Simulation():
             semi_major_axis=self.semi_major_axis,
             minCommunicationsAltitude=self.min_communications_altitude,
             minSatElevation=self.min_sat_elevation,
-            linkingMethod=self.linking_method)
+            linkingMethod=self.linking_method,
+            phasing=self.phasing,
+            earthRotationPeriod=self.earth_rotation_period)
 
         # add ground points to the constillation model
         # from the given file path